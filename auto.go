@@ -0,0 +1,447 @@
+package gocraigslist
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// AutoOptions are the filters of the cars and trucks categories (cta, cto,
+// ctd). Ranges are inclusive whole numbers, either end may be left empty.
+type AutoOptions struct {
+	MakeModel    string         // example = honda civic
+	MinYear      string         // example = 2010
+	MaxYear      string         // example = 2015
+	MinMiles     string         // odometer, example = 10000
+	MaxMiles     string         // odometer, example = 90000
+	TitleStatus  []TitleStatus  // [clean, 1], [salvage, 2], [rebuilt, 3], [parts only, 4], [lien, 5], [missing, 6]
+	Transmission []Transmission // [manual, 1], [automatic, 2], [other, 3]
+	FuelType     []FuelType     // [gas, 1], [diesel, 2], [hybrid, 3], [electric, 4], [other, 6]
+	Drivetrain   []Drivetrain   // [fwd, 1], [rwd, 2], [4wd, 3]
+	Cylinders    []Cylinders    // [3 cylinders, 1], [4 cylinders, 2], [5 cylinders, 3], [6 cylinders, 4], [8 cylinders, 5], [10 cylinders, 6], [12 cylinders, 7], [other, 8]
+	BodyType     []BodyType     // [bus, 1], [convertible, 2], [coupe, 3], [hatchback, 4], [mini-van, 5], [offroad, 6], [pickup, 7], [sedan, 8], [truck, 9], [SUV, 10], [wagon, 11], [van, 12], [other, 13]
+	PaintColor   []PaintColor   // [black, 1], [blue, 2], [green, 3], [grey, 4], [orange, 5], [purple, 6], [red, 7], [silver, 8], [white, 9], [yellow, 10], [custom, 11], [brown, 20]
+}
+
+// TitleStatus is the state of a vehicle's title.
+type TitleStatus string
+
+// Title statuses craigslist can filter by.
+const (
+	TitleClean     TitleStatus = "clean"
+	TitleSalvage   TitleStatus = "salvage"
+	TitleRebuilt   TitleStatus = "rebuilt"
+	TitlePartsOnly TitleStatus = "parts only"
+	TitleLien      TitleStatus = "lien"
+	TitleMissing   TitleStatus = "missing"
+)
+
+// Transmission is a vehicle's transmission.
+type Transmission string
+
+// Transmissions craigslist can filter by.
+const (
+	TransmissionManual    Transmission = "manual"
+	TransmissionAutomatic Transmission = "automatic"
+	TransmissionOther     Transmission = "other"
+)
+
+// FuelType is the fuel a vehicle runs on.
+type FuelType string
+
+// Fuel types craigslist can filter by.
+const (
+	FuelGas      FuelType = "gas"
+	FuelDiesel   FuelType = "diesel"
+	FuelHybrid   FuelType = "hybrid"
+	FuelElectric FuelType = "electric"
+	FuelOther    FuelType = "other"
+)
+
+// Drivetrain is the wheels driving a vehicle.
+type Drivetrain string
+
+// Drivetrains craigslist can filter by.
+const (
+	DrivetrainFWD Drivetrain = "fwd"
+	DrivetrainRWD Drivetrain = "rwd"
+	Drivetrain4WD Drivetrain = "4wd"
+)
+
+// Cylinders is the engine size of a vehicle.
+type Cylinders string
+
+// Engine sizes craigslist can filter by.
+const (
+	Cylinders3     Cylinders = "3 cylinders"
+	Cylinders4     Cylinders = "4 cylinders"
+	Cylinders5     Cylinders = "5 cylinders"
+	Cylinders6     Cylinders = "6 cylinders"
+	Cylinders8     Cylinders = "8 cylinders"
+	Cylinders10    Cylinders = "10 cylinders"
+	Cylinders12    Cylinders = "12 cylinders"
+	CylindersOther Cylinders = "other"
+)
+
+// BodyType is the body style of a vehicle.
+type BodyType string
+
+// Body types craigslist can filter by.
+const (
+	BodyBus         BodyType = "bus"
+	BodyConvertible BodyType = "convertible"
+	BodyCoupe       BodyType = "coupe"
+	BodyHatchback   BodyType = "hatchback"
+	BodyMinivan     BodyType = "mini-van"
+	BodyOffroad     BodyType = "offroad"
+	BodyPickup      BodyType = "pickup"
+	BodySedan       BodyType = "sedan"
+	BodyTruck       BodyType = "truck"
+	BodySUV         BodyType = "SUV"
+	BodyWagon       BodyType = "wagon"
+	BodyVan         BodyType = "van"
+	BodyOther       BodyType = "other"
+)
+
+// PaintColor is the color of a vehicle.
+type PaintColor string
+
+// Paint colors craigslist can filter by.
+const (
+	PaintBlack  PaintColor = "black"
+	PaintBlue   PaintColor = "blue"
+	PaintGreen  PaintColor = "green"
+	PaintGrey   PaintColor = "grey"
+	PaintOrange PaintColor = "orange"
+	PaintPurple PaintColor = "purple"
+	PaintRed    PaintColor = "red"
+	PaintSilver PaintColor = "silver"
+	PaintWhite  PaintColor = "white"
+	PaintYellow PaintColor = "yellow"
+	PaintCustom PaintColor = "custom"
+	PaintBrown  PaintColor = "brown"
+)
+
+// craigslist codes of the AutoOptions filters
+var (
+	titleStatusMap  = map[string]string{"clean": "1", "salvage": "2", "rebuilt": "3", "parts only": "4", "lien": "5", "missing": "6"}
+	transmissionMap = map[string]string{"manual": "1", "automatic": "2", "other": "3"}
+	fuelTypeMap     = map[string]string{"gas": "1", "diesel": "2", "hybrid": "3", "electric": "4", "other": "6"}
+	drivetrainMap   = map[string]string{"fwd": "1", "rwd": "2", "4wd": "3"}
+	cylindersMap    = map[string]string{"3 cylinders": "1", "4 cylinders": "2", "5 cylinders": "3", "6 cylinders": "4", "8 cylinders": "5", "10 cylinders": "6", "12 cylinders": "7", "other": "8"}
+	bodyTypeMap     = map[string]string{"bus": "1", "convertible": "2", "coupe": "3", "hatchback": "4", "mini-van": "5", "offroad": "6", "pickup": "7", "sedan": "8", "truck": "9", "SUV": "10", "wagon": "11", "van": "12", "other": "13"}
+	paintColorMap   = map[string]string{"black": "1", "blue": "2", "green": "3", "grey": "4", "orange": "5", "purple": "6", "red": "7", "silver": "8", "white": "9", "yellow": "10", "custom": "11", "brown": "20"}
+)
+
+var (
+	titleStatusEnum  = enum{kind: "title status", codes: titleStatusMap}
+	transmissionEnum = enum{kind: "transmission", codes: transmissionMap}
+	fuelTypeEnum     = enum{kind: "fuel type", codes: fuelTypeMap}
+	drivetrainEnum   = enum{kind: "drivetrain", codes: drivetrainMap}
+	cylindersEnum    = enum{kind: "cylinders", codes: cylindersMap}
+	bodyTypeEnum     = enum{kind: "body type", codes: bodyTypeMap}
+	paintColorEnum   = enum{kind: "paint color", codes: paintColorMap}
+)
+
+// Code is the value of the title status in a search url, empty when unknown.
+func (t TitleStatus) Code() string { return titleStatusMap[string(t)] }
+
+// String implements fmt.Stringer.
+func (t TitleStatus) String() string { return string(t) }
+
+// UnmarshalJSON accepts either the name or the code of a title status.
+func (t *TitleStatus) UnmarshalJSON(data []byte) error {
+	return titleStatusEnum.unmarshal(data, (*string)(t))
+}
+
+// ParseTitleStatus looks up a title status by name or code, ignoring case.
+func ParseTitleStatus(s string) (TitleStatus, error) {
+	name, err := titleStatusEnum.parse(s)
+	return TitleStatus(name), err
+}
+
+// Code is the value of the transmission in a search url, empty when unknown.
+func (t Transmission) Code() string { return transmissionMap[string(t)] }
+
+// String implements fmt.Stringer.
+func (t Transmission) String() string { return string(t) }
+
+// UnmarshalJSON accepts either the name or the code of a transmission.
+func (t *Transmission) UnmarshalJSON(data []byte) error {
+	return transmissionEnum.unmarshal(data, (*string)(t))
+}
+
+// ParseTransmission looks up a transmission by name or code, ignoring case.
+func ParseTransmission(s string) (Transmission, error) {
+	name, err := transmissionEnum.parse(s)
+	return Transmission(name), err
+}
+
+// Code is the value of the fuel type in a search url, empty when unknown.
+func (f FuelType) Code() string { return fuelTypeMap[string(f)] }
+
+// String implements fmt.Stringer.
+func (f FuelType) String() string { return string(f) }
+
+// UnmarshalJSON accepts either the name or the code of a fuel type.
+func (f *FuelType) UnmarshalJSON(data []byte) error {
+	return fuelTypeEnum.unmarshal(data, (*string)(f))
+}
+
+// ParseFuelType looks up a fuel type by name or code, ignoring case.
+func ParseFuelType(s string) (FuelType, error) {
+	name, err := fuelTypeEnum.parse(s)
+	return FuelType(name), err
+}
+
+// Code is the value of the drivetrain in a search url, empty when unknown.
+func (d Drivetrain) Code() string { return drivetrainMap[string(d)] }
+
+// String implements fmt.Stringer.
+func (d Drivetrain) String() string { return string(d) }
+
+// UnmarshalJSON accepts either the name or the code of a drivetrain.
+func (d *Drivetrain) UnmarshalJSON(data []byte) error {
+	return drivetrainEnum.unmarshal(data, (*string)(d))
+}
+
+// ParseDrivetrain looks up a drivetrain by name or code, ignoring case.
+func ParseDrivetrain(s string) (Drivetrain, error) {
+	name, err := drivetrainEnum.parse(s)
+	return Drivetrain(name), err
+}
+
+// Code is the value of the engine size in a search url, empty when unknown.
+func (c Cylinders) Code() string { return cylindersMap[string(c)] }
+
+// String implements fmt.Stringer.
+func (c Cylinders) String() string { return string(c) }
+
+// UnmarshalJSON accepts either the name or the code of an engine size.
+func (c *Cylinders) UnmarshalJSON(data []byte) error {
+	return cylindersEnum.unmarshal(data, (*string)(c))
+}
+
+// ParseCylinders looks up an engine size by name or code, ignoring case.
+func ParseCylinders(s string) (Cylinders, error) {
+	name, err := cylindersEnum.parse(s)
+	return Cylinders(name), err
+}
+
+// Code is the value of the body type in a search url, empty when unknown.
+func (b BodyType) Code() string { return bodyTypeMap[string(b)] }
+
+// String implements fmt.Stringer.
+func (b BodyType) String() string { return string(b) }
+
+// UnmarshalJSON accepts either the name or the code of a body type.
+func (b *BodyType) UnmarshalJSON(data []byte) error {
+	return bodyTypeEnum.unmarshal(data, (*string)(b))
+}
+
+// ParseBodyType looks up a body type by name or code, ignoring case.
+func ParseBodyType(s string) (BodyType, error) {
+	name, err := bodyTypeEnum.parse(s)
+	return BodyType(name), err
+}
+
+// Code is the value of the paint color in a search url, empty when unknown.
+func (p PaintColor) Code() string { return paintColorMap[string(p)] }
+
+// String implements fmt.Stringer.
+func (p PaintColor) String() string { return string(p) }
+
+// UnmarshalJSON accepts either the name or the code of a paint color.
+func (p *PaintColor) UnmarshalJSON(data []byte) error {
+	return paintColorEnum.unmarshal(data, (*string)(p))
+}
+
+// ParsePaintColor looks up a paint color by name or code, ignoring case.
+func ParsePaintColor(s string) (PaintColor, error) {
+	name, err := paintColorEnum.parse(s)
+	return PaintColor(name), err
+}
+
+// autoCategories are the categories AutoOptions apply to.
+var autoCategories = map[string]bool{"cta": true, "cto": true, "ctd": true}
+
+// autoFilter is one multi valued filter of AutoOptions, names reads the string
+// values of its typed slice and add appends a name to it.
+type autoFilter struct {
+	param string
+	label string
+	codes map[string]string
+	names func(a AutoOptions) []string
+	add   func(a *AutoOptions, name string)
+}
+
+var autoFilters = []autoFilter{
+	{
+		param: "auto_title_status",
+		label: "title status",
+		codes: titleStatusMap,
+		names: func(a AutoOptions) []string {
+			names := []string{}
+			for _, v := range a.TitleStatus {
+				names = append(names, string(v))
+			}
+			return names
+		},
+		add: func(a *AutoOptions, name string) { a.TitleStatus = append(a.TitleStatus, TitleStatus(name)) },
+	},
+	{
+		param: "auto_transmission",
+		label: "transmission",
+		codes: transmissionMap,
+		names: func(a AutoOptions) []string {
+			names := []string{}
+			for _, v := range a.Transmission {
+				names = append(names, string(v))
+			}
+			return names
+		},
+		add: func(a *AutoOptions, name string) { a.Transmission = append(a.Transmission, Transmission(name)) },
+	},
+	{
+		param: "auto_fuel_type",
+		label: "fuel type",
+		codes: fuelTypeMap,
+		names: func(a AutoOptions) []string {
+			names := []string{}
+			for _, v := range a.FuelType {
+				names = append(names, string(v))
+			}
+			return names
+		},
+		add: func(a *AutoOptions, name string) { a.FuelType = append(a.FuelType, FuelType(name)) },
+	},
+	{
+		param: "auto_drivetrain",
+		label: "drivetrain",
+		codes: drivetrainMap,
+		names: func(a AutoOptions) []string {
+			names := []string{}
+			for _, v := range a.Drivetrain {
+				names = append(names, string(v))
+			}
+			return names
+		},
+		add: func(a *AutoOptions, name string) { a.Drivetrain = append(a.Drivetrain, Drivetrain(name)) },
+	},
+	{
+		param: "auto_cylinders",
+		label: "cylinders",
+		codes: cylindersMap,
+		names: func(a AutoOptions) []string {
+			names := []string{}
+			for _, v := range a.Cylinders {
+				names = append(names, string(v))
+			}
+			return names
+		},
+		add: func(a *AutoOptions, name string) { a.Cylinders = append(a.Cylinders, Cylinders(name)) },
+	},
+	{
+		param: "auto_bodytype",
+		label: "body type",
+		codes: bodyTypeMap,
+		names: func(a AutoOptions) []string {
+			names := []string{}
+			for _, v := range a.BodyType {
+				names = append(names, string(v))
+			}
+			return names
+		},
+		add: func(a *AutoOptions, name string) { a.BodyType = append(a.BodyType, BodyType(name)) },
+	},
+	{
+		param: "auto_paint",
+		label: "paint color",
+		codes: paintColorMap,
+		names: func(a AutoOptions) []string {
+			names := []string{}
+			for _, v := range a.PaintColor {
+				names = append(names, string(v))
+			}
+			return names
+		},
+		add: func(a *AutoOptions, name string) { a.PaintColor = append(a.PaintColor, PaintColor(name)) },
+	},
+}
+
+// args encodes the filters as query arguments in the format of FormatURL.
+func (a AutoOptions) args() string {
+	var args string
+	if a.MakeModel != "" {
+		args += "&auto_make_model=" + formatTerm(a.MakeModel)
+	}
+
+	for _, r := range []struct{ param, value string }{
+		{"min_auto_year", a.MinYear},
+		{"max_auto_year", a.MaxYear},
+		{"min_auto_miles", a.MinMiles},
+		{"max_auto_miles", a.MaxMiles},
+	} {
+		if r.value != "" {
+			args += "&" + r.param + "=" + r.value
+		}
+	}
+
+	for _, f := range autoFilters {
+		for _, name := range f.names(a) {
+			args += "&" + f.param + "=" + f.codes[name]
+		}
+	}
+
+	return args
+}
+
+// validate reports the first filter FormatURL cannot encode, or any filter
+// on a category other than cars and trucks.
+func (a AutoOptions) validate(category string) error {
+	err := validateRange("year", a.MinYear, a.MaxYear)
+	if err != nil {
+		return err
+	}
+
+	err = validateRange("miles", a.MinMiles, a.MaxMiles)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range autoFilters {
+		for _, name := range f.names(a) {
+			if _, has := f.codes[name]; !has {
+				return fmt.Errorf("%w: unknown %s %q", ErrInvalidOptions, f.label, name)
+			}
+		}
+	}
+
+	if a.args() != "" && !autoCategories[category] {
+		return fmt.Errorf("%w: auto filters require the cta, cto or ctd category, not %q", ErrInvalidOptions, category)
+	}
+
+	return nil
+}
+
+// parseAutoOptions reads the filters of AutoOptions from a search url query.
+func parseAutoOptions(q url.Values) (AutoOptions, error) {
+	a := AutoOptions{
+		MakeModel: q.Get("auto_make_model"),
+		MinYear:   q.Get("min_auto_year"),
+		MaxYear:   q.Get("max_auto_year"),
+		MinMiles:  q.Get("min_auto_miles"),
+		MaxMiles:  q.Get("max_auto_miles"),
+	}
+
+	for _, f := range autoFilters {
+		for _, code := range q[f.param] {
+			name, has := lookupName(f.codes, code)
+			if !has {
+				return a, fmt.Errorf("unknown %s code: %s", f.label, code)
+			}
+			f.add(&a, name)
+		}
+	}
+
+	return a, nil
+}
@@ -0,0 +1,356 @@
+package gocraigslist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// categoriesURL lists every category craigslist has.
+const categoriesURL = "http://reference.craigslist.org/Categories"
+
+// Sections categories are grouped under on the craigslist home page.
+const (
+	SectionForSale   = "for sale"
+	SectionHousing   = "housing"
+	SectionJobs      = "jobs"
+	SectionServices  = "services"
+	SectionCommunity = "community"
+	SectionGigs      = "gigs"
+	SectionResumes   = "resumes"
+	SectionUnknown   = "unknown" // reference api categories of a type the package does not know
+)
+
+// Category is a craigslist category. Owner and Dealer are the codes of the
+// variants only listing postings by owners or dealers, empty when the
+// category has none.
+type Category struct {
+	Code    string
+	Name    string
+	Section string
+	Owner   string
+	Dealer  string
+}
+
+// DefaultCategories is the catalog embedded in the package. A Client uses it
+// until RefreshCategories loads the current list from craigslist.
+var DefaultCategories = []Category{
+	{Code: "sss", Name: "all for sale", Section: SectionForSale, Owner: "sso", Dealer: "ssq"},
+	{Code: "ata", Name: "antiques", Section: SectionForSale, Owner: "ato", Dealer: "atd"},
+	{Code: "ppa", Name: "appliances", Section: SectionForSale, Owner: "ppo", Dealer: "ppd"},
+	{Code: "ara", Name: "arts & crafts", Section: SectionForSale, Owner: "aro", Dealer: "ard"},
+	{Code: "sna", Name: "atv/utv/sno", Section: SectionForSale, Owner: "sno", Dealer: "snd"},
+	{Code: "pta", Name: "auto parts", Section: SectionForSale, Owner: "pto", Dealer: "ptd"},
+	{Code: "wta", Name: "auto wheels & tires", Section: SectionForSale, Owner: "wto", Dealer: "wtd"},
+	{Code: "ava", Name: "aviation", Section: SectionForSale, Owner: "avo", Dealer: "avd"},
+	{Code: "baa", Name: "baby & kid stuff", Section: SectionForSale, Owner: "bab", Dealer: "bad"},
+	{Code: "bar", Name: "barter", Section: SectionForSale},
+	{Code: "bia", Name: "bicycles", Section: SectionForSale, Owner: "bik", Dealer: "bid"},
+	{Code: "boo", Name: "boats", Section: SectionForSale, Owner: "boa", Dealer: "bod"},
+	{Code: "bpa", Name: "boat parts & accessories", Section: SectionForSale, Owner: "bpo", Dealer: "bpd"},
+	{Code: "bka", Name: "books & magazines", Section: SectionForSale, Owner: "bko", Dealer: "bkd"},
+	{Code: "bfa", Name: "business/commercial", Section: SectionForSale, Owner: "bfo", Dealer: "bfd"},
+	{Code: "cta", Name: "cars & trucks", Section: SectionForSale, Owner: "cto", Dealer: "ctd"},
+	{Code: "ema", Name: "cds/dvds/vhs", Section: SectionForSale, Owner: "emd", Dealer: "emq"},
+	{Code: "moa", Name: "cell phones", Section: SectionForSale, Owner: "mob", Dealer: "mod"},
+	{Code: "cla", Name: "clothing & accessories", Section: SectionForSale, Owner: "clo", Dealer: "cld"},
+	{Code: "cba", Name: "collectibles", Section: SectionForSale, Owner: "cbo", Dealer: "cbd"},
+	{Code: "syp", Name: "computer parts", Section: SectionForSale, Owner: "sop", Dealer: "sdp"},
+	{Code: "sya", Name: "computers", Section: SectionForSale, Owner: "sys", Dealer: "syd"},
+	{Code: "ela", Name: "electronics", Section: SectionForSale, Owner: "ele", Dealer: "eld"},
+	{Code: "gra", Name: "farm & garden", Section: SectionForSale, Owner: "grq", Dealer: "grd"},
+	{Code: "zip", Name: "free stuff", Section: SectionForSale},
+	{Code: "fua", Name: "furniture", Section: SectionForSale, Owner: "fuo", Dealer: "fud"},
+	{Code: "foa", Name: "general for sale", Section: SectionForSale, Owner: "for", Dealer: "fod"},
+	{Code: "gms", Name: "garage & moving sales", Section: SectionForSale},
+	{Code: "haa", Name: "health and beauty", Section: SectionForSale, Owner: "hab", Dealer: "had"},
+	{Code: "hva", Name: "heavy equipment", Section: SectionForSale, Owner: "hvo", Dealer: "hvd"},
+	{Code: "hsa", Name: "household items", Section: SectionForSale, Owner: "hsh", Dealer: "hsd"},
+	{Code: "jwa", Name: "jewelry", Section: SectionForSale, Owner: "jwl", Dealer: "jwd"},
+	{Code: "maa", Name: "materials", Section: SectionForSale, Owner: "mat", Dealer: "mad"},
+	{Code: "mpa", Name: "motorcycle parts", Section: SectionForSale, Owner: "mpo", Dealer: "mpd"},
+	{Code: "mca", Name: "motorcycles/scooters", Section: SectionForSale, Owner: "mcy", Dealer: "mcd"},
+	{Code: "msa", Name: "musical instruments", Section: SectionForSale, Owner: "msg", Dealer: "msd"},
+	{Code: "pha", Name: "photo/video", Section: SectionForSale, Owner: "pho", Dealer: "phd"},
+	{Code: "rva", Name: "rvs", Section: SectionForSale, Owner: "rvs", Dealer: "rvd"},
+	{Code: "sga", Name: "sporting goods", Section: SectionForSale, Owner: "spo", Dealer: "sgd"},
+	{Code: "tia", Name: "tickets", Section: SectionForSale, Owner: "tix", Dealer: "tid"},
+	{Code: "tla", Name: "tools", Section: SectionForSale, Owner: "tls", Dealer: "tld"},
+	{Code: "taa", Name: "toys & games", Section: SectionForSale, Owner: "tag", Dealer: "tad"},
+	{Code: "tra", Name: "trailers", Section: SectionForSale, Owner: "tro", Dealer: "trb"},
+	{Code: "vga", Name: "video gaming", Section: SectionForSale, Owner: "vgm", Dealer: "vgd"},
+	{Code: "waa", Name: "wanted", Section: SectionForSale, Owner: "wan", Dealer: "wad"},
+
+	{Code: "hhh", Name: "all housing", Section: SectionHousing},
+	{Code: "apa", Name: "apartments / housing for rent", Section: SectionHousing},
+	{Code: "swp", Name: "housing swap", Section: SectionHousing},
+	{Code: "hsw", Name: "housing wanted", Section: SectionHousing},
+	{Code: "off", Name: "office & commercial", Section: SectionHousing},
+	{Code: "prk", Name: "parking & storage", Section: SectionHousing},
+	{Code: "rea", Name: "real estate for sale", Section: SectionHousing, Owner: "reo", Dealer: "reb"},
+	{Code: "roo", Name: "rooms & shares", Section: SectionHousing},
+	{Code: "sub", Name: "sublets & temporary", Section: SectionHousing},
+	{Code: "vac", Name: "vacation rentals", Section: SectionHousing},
+
+	{Code: "jjj", Name: "all jobs", Section: SectionJobs},
+	{Code: "acc", Name: "accounting/finance", Section: SectionJobs},
+	{Code: "ofc", Name: "admin/office", Section: SectionJobs},
+	{Code: "egr", Name: "architect/engineer/cad", Section: SectionJobs},
+	{Code: "med", Name: "art/media/design", Section: SectionJobs},
+	{Code: "sci", Name: "biotech/science", Section: SectionJobs},
+	{Code: "bus", Name: "business/mgmt", Section: SectionJobs},
+	{Code: "csr", Name: "customer service", Section: SectionJobs},
+	{Code: "edu", Name: "education/teaching", Section: SectionJobs},
+	{Code: "fbh", Name: "food/beverage/hospitality", Section: SectionJobs},
+	{Code: "lab", Name: "general labor", Section: SectionJobs},
+	{Code: "gov", Name: "government", Section: SectionJobs},
+	{Code: "hum", Name: "human resource", Section: SectionJobs},
+	{Code: "eng", Name: "internet engineering", Section: SectionJobs},
+	{Code: "lgl", Name: "legal/paralegal", Section: SectionJobs},
+	{Code: "mnu", Name: "manufacturing", Section: SectionJobs},
+	{Code: "mar", Name: "marketing/advertising/pr", Section: SectionJobs},
+	{Code: "hea", Name: "medical/health", Section: SectionJobs},
+	{Code: "npo", Name: "nonprofit", Section: SectionJobs},
+	{Code: "rej", Name: "real estate", Section: SectionJobs},
+	{Code: "ret", Name: "retail/wholesale", Section: SectionJobs},
+	{Code: "sls", Name: "sales", Section: SectionJobs},
+	{Code: "spa", Name: "salon/spa/fitness", Section: SectionJobs},
+	{Code: "sec", Name: "security", Section: SectionJobs},
+	{Code: "trd", Name: "skilled trades/artisan", Section: SectionJobs},
+	{Code: "sof", Name: "software/qa/dba/etc", Section: SectionJobs},
+	{Code: "sad", Name: "systems/networking", Section: SectionJobs},
+	{Code: "tch", Name: "technical support", Section: SectionJobs},
+	{Code: "trp", Name: "transportation", Section: SectionJobs},
+	{Code: "tfr", Name: "tv/film/video/radio", Section: SectionJobs},
+	{Code: "web", Name: "web/html/info design", Section: SectionJobs},
+	{Code: "wri", Name: "writing/editing", Section: SectionJobs},
+
+	{Code: "bbb", Name: "all services", Section: SectionServices},
+	{Code: "aos", Name: "automotive services", Section: SectionServices},
+	{Code: "bts", Name: "beauty services", Section: SectionServices},
+	{Code: "cms", Name: "cell phone / mobile services", Section: SectionServices},
+	{Code: "cps", Name: "computer services", Section: SectionServices},
+	{Code: "crs", Name: "creative services", Section: SectionServices},
+	{Code: "cys", Name: "cycle services", Section: SectionServices},
+	{Code: "evs", Name: "event services", Section: SectionServices},
+	{Code: "fgs", Name: "farm & garden services", Section: SectionServices},
+	{Code: "fns", Name: "financial services", Section: SectionServices},
+	{Code: "hws", Name: "health/wellness services", Section: SectionServices},
+	{Code: "hss", Name: "household services", Section: SectionServices},
+	{Code: "lbs", Name: "labor & moving", Section: SectionServices},
+	{Code: "lgs", Name: "legal services", Section: SectionServices},
+	{Code: "lss", Name: "lessons & tutoring", Section: SectionServices},
+	{Code: "mas", Name: "marine services", Section: SectionServices},
+	{Code: "pas", Name: "pet services", Section: SectionServices},
+	{Code: "rts", Name: "real estate services", Section: SectionServices},
+	{Code: "sks", Name: "skilled trade services", Section: SectionServices},
+	{Code: "biz", Name: "small biz ads", Section: SectionServices},
+	{Code: "trv", Name: "travel/vacation services", Section: SectionServices},
+	{Code: "wet", Name: "writing / editing / translation", Section: SectionServices},
+
+	{Code: "ccc", Name: "all community", Section: SectionCommunity},
+	{Code: "act", Name: "activity partners", Section: SectionCommunity},
+	{Code: "ats", Name: "artists", Section: SectionCommunity},
+	{Code: "kid", Name: "childcare", Section: SectionCommunity},
+	{Code: "cls", Name: "classes", Section: SectionCommunity},
+	{Code: "eve", Name: "events", Section: SectionCommunity},
+	{Code: "com", Name: "general community", Section: SectionCommunity},
+	{Code: "grp", Name: "groups", Section: SectionCommunity},
+	{Code: "vnn", Name: "local news and views", Section: SectionCommunity},
+	{Code: "laf", Name: "lost & found", Section: SectionCommunity},
+	{Code: "mis", Name: "missed connections", Section: SectionCommunity},
+	{Code: "muc", Name: "musicians", Section: SectionCommunity},
+	{Code: "pet", Name: "pets", Section: SectionCommunity},
+	{Code: "pol", Name: "politics", Section: SectionCommunity},
+	{Code: "rid", Name: "rideshare", Section: SectionCommunity},
+	{Code: "vol", Name: "volunteers", Section: SectionCommunity},
+
+	{Code: "ggg", Name: "all gigs", Section: SectionGigs},
+	{Code: "cpg", Name: "computer gigs", Section: SectionGigs},
+	{Code: "crg", Name: "creative gigs", Section: SectionGigs},
+	{Code: "cwg", Name: "crew gigs", Section: SectionGigs},
+	{Code: "dmg", Name: "domestic gigs", Section: SectionGigs},
+	{Code: "evg", Name: "event gigs", Section: SectionGigs},
+	{Code: "lbg", Name: "labor gigs", Section: SectionGigs},
+	{Code: "tlg", Name: "talent gigs", Section: SectionGigs},
+	{Code: "wrg", Name: "writing gigs", Section: SectionGigs},
+
+	{Code: "rrr", Name: "resumes", Section: SectionResumes},
+}
+
+// Variant is the code of the category limited to postings by seller, empty
+// when the category has no such variant. PostedByAll is the category itself.
+func (c Category) Variant(seller PostedBy) string {
+	switch seller {
+	case "", PostedByAll:
+		return c.Code
+	case PostedByOwner:
+		return c.Owner
+	case PostedByDealer:
+		return c.Dealer
+	}
+
+	return ""
+}
+
+// referenceSections maps the Type of a reference api category to its section.
+var referenceSections = map[string]string{
+	"S": SectionForSale,
+	"H": SectionHousing,
+	"J": SectionJobs,
+	"B": SectionServices,
+	"C": SectionCommunity,
+	"G": SectionGigs,
+	"R": SectionResumes,
+}
+
+// referenceCategory is a category as listed by the reference api.
+type referenceCategory struct {
+	Abbreviation string
+	CategoryID   int
+	Description  string
+	Type         string
+}
+
+// LookupCategory finds a category of DefaultCategories by code or name,
+// ignoring case. The owner and dealer codes of a category resolve to it.
+func LookupCategory(nameOrCode string) (Category, bool) {
+	return lookupCategory(DefaultCategories, nameOrCode)
+}
+
+// LookupCategory is the package LookupCategory using the client's catalog.
+func (c *Client) LookupCategory(nameOrCode string) (Category, bool) {
+	return lookupCategory(c.categories(), nameOrCode)
+}
+
+// categories returns the catalog of the client, DefaultCategories until
+// RefreshCategories is called.
+func (c *Client) categories() []Category {
+	c.categoriesMu.RLock()
+	defer c.categoriesMu.RUnlock()
+
+	if c.Categories == nil {
+		return DefaultCategories
+	}
+	return c.Categories
+}
+
+// categoryCodePattern matches values shaped like a category code. Codes
+// missing from the catalog are still searched as is, no catalog lists every
+// category craigslist has.
+var categoryCodePattern = regexp.MustCompile(`^[a-z]{3}$`)
+
+// categoryCode is the code of a category given by code or name, the value
+// as is when the catalog has neither.
+func categoryCode(catalog []Category, nameOrCode string) string {
+	if _, has := categoryByCode(catalog, nameOrCode); has {
+		return nameOrCode
+	}
+
+	if category, has := lookupCategory(catalog, nameOrCode); has {
+		return category.Code
+	}
+
+	return nameOrCode
+}
+
+// categoryByCode finds the category a code, or one of its owner and dealer
+// variants, belongs to.
+func categoryByCode(catalog []Category, code string) (Category, bool) {
+	for _, category := range catalog {
+		if category.Code == code || category.Owner == code || category.Dealer == code {
+			return category, true
+		}
+	}
+
+	return Category{}, false
+}
+
+func lookupCategory(catalog []Category, nameOrCode string) (Category, bool) {
+	key := strings.ToLower(strings.TrimSpace(nameOrCode))
+	if key == "" {
+		return Category{}, false
+	}
+
+	if category, has := categoryByCode(catalog, key); has {
+		return category, true
+	}
+
+	for _, category := range catalog {
+		if strings.ToLower(category.Name) == key {
+			return category, true
+		}
+	}
+
+	return Category{}, false
+}
+
+// RefreshCategories loads the current categories from craigslist's
+// reference api into Client.Categories, it is safe to call while searches
+// are running. The api lists owner and dealer
+// variants as categories of their own named "<category> - by owner" and
+// "<category> - by dealer", they are folded into the category they belong to.
+func (c *Client) RefreshCategories(ctx context.Context) ([]Category, error) {
+	resp, err := c.Request.fetch(ctx, categoriesURL)
+	if err != nil {
+		return nil, fmt.Errorf("error sending http request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the http body: %v", err)
+	}
+
+	reference := []referenceCategory{}
+	err = json.Unmarshal(data, &reference)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode categories: %v", err)
+	}
+
+	catalog := []Category{}
+	index := map[string]int{}
+	variants := []referenceCategory{}
+	for _, ref := range reference {
+		code := strings.ToLower(ref.Abbreviation)
+		name := strings.TrimSpace(ref.Description)
+		if strings.HasSuffix(name, " - by owner") || strings.HasSuffix(name, " - by dealer") {
+			variants = append(variants, ref)
+			continue
+		}
+
+		section, has := referenceSections[ref.Type]
+		if !has {
+			section = SectionUnknown
+		}
+
+		index[strings.ToLower(name)] = len(catalog)
+		catalog = append(catalog, Category{Code: code, Name: name, Section: section})
+	}
+
+	for _, ref := range variants {
+		name := strings.TrimSpace(ref.Description)
+		owner := strings.HasSuffix(name, " - by owner")
+		name = strings.TrimSuffix(strings.TrimSuffix(name, " - by owner"), " - by dealer")
+
+		i, has := index[strings.ToLower(name)]
+		if !has {
+			continue
+		}
+
+		if owner {
+			catalog[i].Owner = strings.ToLower(ref.Abbreviation)
+		} else {
+			catalog[i].Dealer = strings.ToLower(ref.Abbreviation)
+		}
+	}
+
+	c.categoriesMu.Lock()
+	c.Categories = catalog
+	c.categoriesMu.Unlock()
+
+	return catalog, nil
+}
@@ -0,0 +1,123 @@
+package gocraigslist
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategories(t *testing.T) {
+	t.Run("should have unique codes", func(t *testing.T) {
+		seen := map[string]bool{}
+		for _, category := range DefaultCategories {
+			for _, code := range []string{category.Code, category.Owner, category.Dealer} {
+				if code == "" {
+					continue
+				}
+				assert.False(t, seen[code], code)
+				seen[code] = true
+			}
+		}
+	})
+
+	t.Run("should lookup categories by code or name", func(t *testing.T) {
+		for _, given := range []string{"cta", "CTA", "cto", "ctd", "cars & trucks", "Cars & Trucks"} {
+			category, has := LookupCategory(given)
+			assert.True(t, has, given)
+			assert.Equal(t, Category{Code: "cta", Name: "cars & trucks", Section: SectionForSale, Owner: "cto", Dealer: "ctd"}, category, given)
+		}
+
+		category, has := LookupCategory("apa")
+		assert.True(t, has)
+		assert.Equal(t, SectionHousing, category.Section)
+
+		for _, given := range []string{"", "xyz", "cars"} {
+			_, has := LookupCategory(given)
+			assert.False(t, has, given)
+		}
+	})
+
+	t.Run("should validate category codes", func(t *testing.T) {
+		assert.NoError(t, Options{Category: "fua"}.Validate())
+		assert.NoError(t, Options{Category: "cto"}.Validate())
+		assert.NoError(t, Options{Category: "furniture"}.Validate())
+		assert.NoError(t, Options{Category: "Cars & Trucks", Auto: AutoOptions{MinYear: "2010"}}.Validate())
+
+		// the catalog cannot list every category, unknown codes are searched as is
+		for _, given := range []string{"syp", "foa", "xyz"} {
+			assert.NoError(t, Options{Category: given}.Validate(), given)
+		}
+
+		for _, given := range []string{"furnitures", "XYZ", "cars & trucks - by owner"} {
+			err := Options{Category: given}.Validate()
+			assert.True(t, errors.Is(err, ErrInvalidOptions), given)
+			assert.EqualError(t, err, `invalid options: unknown category "`+given+`"`)
+		}
+
+		err := Options{Category: "xyz", PostedBy: "owner"}.Validate()
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+	})
+
+	t.Run("should format category names as their codes", func(t *testing.T) {
+		client := NewClient("newyork")
+
+		url := client.FormatURL("couch", Options{Category: "furniture"})
+		assert.Equal(t, "https://newyork.craigslist.org/search/fua?query=couch&sort=rel", url)
+
+		url = client.FormatURL("couch", Options{Category: "Furniture", Seller: PostedByOwner})
+		assert.Equal(t, "https://newyork.craigslist.org/search/fuo?query=couch&sort=rel", url)
+	})
+
+	t.Run("should refresh categories from the reference api", func(t *testing.T) {
+		reference := []byte(`[
+			{"Abbreviation": "cta", "CategoryID": 145, "Description": "cars & trucks", "Type": "S"},
+			{"Abbreviation": "cto", "CategoryID": 145, "Description": "cars & trucks - by owner", "Type": "S"},
+			{"Abbreviation": "ctd", "CategoryID": 145, "Description": "cars & trucks - by dealer", "Type": "S"},
+			{"Abbreviation": "apa", "CategoryID": 1, "Description": "apts/housing for rent", "Type": "H"},
+			{"Abbreviation": "zzz", "CategoryID": 999, "Description": "brand new", "Type": "X"},
+			{"Abbreviation": "qqo", "CategoryID": 998, "Description": "unknown - by owner", "Type": "S"}
+		]`)
+		m := &mockFetcher{routes: map[string][]byte{categoriesURL: reference}}
+		client := Client{Location: "newyork", Request: m}
+
+		categories, err := client.RefreshCategories(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []Category{
+			{Code: "cta", Name: "cars & trucks", Section: SectionForSale, Owner: "cto", Dealer: "ctd"},
+			{Code: "apa", Name: "apts/housing for rent", Section: SectionHousing},
+			{Code: "zzz", Name: "brand new", Section: SectionUnknown},
+		}, categories)
+		assert.Equal(t, categories, client.Categories)
+
+		category, has := client.LookupCategory("zzz")
+		assert.True(t, has)
+		assert.Equal(t, "brand new", category.Name)
+
+		_, err = client.BuildURL("xbox", Options{Category: "zzz"})
+		assert.NoError(t, err)
+
+		_, err = client.BuildURL("xbox", Options{Category: "furniture"})
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+	})
+
+	t.Run("should refresh categories while searching", func(t *testing.T) {
+		m := &mockFetcher{routes: map[string][]byte{categoriesURL: []byte(`[{"Abbreviation": "fua", "Description": "furniture", "Type": "S"}]`)}}
+		client := &Client{Location: "newyork", Request: m}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				client.FormatURL("couch", Options{Category: "furniture"})
+			}
+		}()
+
+		for i := 0; i < 10; i++ {
+			_, err := client.RefreshCategories(context.Background())
+			assert.NoError(t, err)
+		}
+		<-done
+	})
+}
@@ -0,0 +1,87 @@
+package gocraigslist
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// sniffLength is how much of a page is searched for a meta charset, the same
+// limit browsers use.
+const sniffLength = 1024
+
+var (
+	metaCharset   = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-zA-Z0-9_:.\-]+)`)
+	xmlDeclEncode = regexp.MustCompile(`(?i)^<\?xml[^>]+encoding\s*=\s*["']([a-zA-Z0-9_:.\-]+)["']`)
+)
+
+// detectCharset returns the lower cased charset of a page, taken from the
+// Content-Type header, then a meta tag or xml declaration in the page itself,
+// and defaulting to utf-8.
+func detectCharset(contentType string, data []byte) string {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		return strings.ToLower(params["charset"])
+	}
+
+	if bytes.HasPrefix(data, []byte("\xef\xbb\xbf")) {
+		return "utf-8"
+	}
+
+	head := data
+	if len(head) > sniffLength {
+		head = head[:sniffLength]
+	}
+
+	if match := xmlDeclEncode.FindSubmatch(bytes.TrimSpace(head)); match != nil {
+		return strings.ToLower(string(match[1]))
+	}
+
+	if match := metaCharset.FindSubmatch(head); match != nil {
+		return strings.ToLower(string(match[1]))
+	}
+
+	return "utf-8"
+}
+
+// toUTF8 converts data from the charset labeled label to utf-8. Labels are
+// resolved the way browsers do, so iso-8859-1 decodes as windows-1252 and
+// shift_jis, euc-jp and the other legacy encodings are supported.
+func toUTF8(data []byte, label string) ([]byte, error) {
+	encoding, name := charset.Lookup(label)
+	if encoding == nil {
+		return nil, fmt.Errorf("unsupported charset: %s", label)
+	}
+
+	if name == "utf-8" {
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+		if !utf8.Valid(data) {
+			return bytes.ToValidUTF8(data, []byte("�")), nil
+		}
+		return data, nil
+	}
+
+	return encoding.NewDecoder().Bytes(data)
+}
+
+// charsetReader converts xml documents declaring a charset other than utf-8,
+// it is used as the CharsetReader of an xml.Decoder.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	data, err := ioutil.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := toUTF8(data, strings.ToLower(label))
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(converted), nil
+}
@@ -1,12 +1,16 @@
 package gocraigslist
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,7 +20,6 @@ const (
 	base        = "craigslist.org"
 	defCategory = "sss" // this represent a text search in craigslist and not a specific category
 	defPath     = "/search/"
-	defSort     = "&sort=rel"
 
 	// other useful constants
 	defCategoryOwner  = "sso"
@@ -29,14 +32,43 @@ const (
 	bundleDuplicates  = "&bundleDuplicates="
 	cryptoCurrencyOK  = "&crypto_currency_ok="
 	deliveryAvailable = "&delivery_available="
+	searchNearby      = "&searchNearby="
+	nearbyArea        = "&nearbyArea="
 	minPrice          = "&min_price="
 	maxPrice          = "&max_price="
+	searchDistance    = "&search_distance="
+	postal            = "&postal="
+	latitude          = "&lat="
+	longitude         = "&lon="
 	language          = "&language="
 	condition         = "&condition="
 	page              = "&s="
+	sortOrder         = "&sort="
 
 	// timezone url
 	tzURL = "http://reference.craigslist.org/Areas"
+
+	// json search api
+	jsonAPIURL = "https://sapi.craigslist.org/web/v8/postings/search/full"
+)
+
+// craigslist codes of the Condition and Language options
+var (
+	conditionMap = map[string]string{"new": "10", "like new": "20", "excellent": "30", "good": "40", "fair": "50", "salvage": "60"}
+	languageMap  = map[string]string{"af": "1", "ca": "2", "da": "3", "de": "4", "en": "5", "es": "6", "fi": "7", "fr": "8", "it": "9", "nl": "10", "no": "11", "pt": "12", "sv": "13", "tl": "14", "tr": "15", "zh": "16", "ar": "17", "ja": "18", "ko": "19", "ru": "20", "vi": "21"}
+)
+
+// Backend selects which craigslist endpoint search results are requested from.
+type Backend int
+
+const (
+	// BackendHTML requests the search page as html, this is the default.
+	BackendHTML Backend = iota
+	// BackendJSON requests results from craigslist's JSON search api.
+	BackendJSON
+	// BackendRSS requests the rss variant of the search page. The feed has
+	// no total count so a Result built from it never paginates.
+	BackendRSS
 )
 
 // API represents the interface with Craigslist.
@@ -53,6 +85,69 @@ type Client struct {
 	Location    string
 	Request     fetcher
 	TimezoneMap map[string]string
+	AreaMap     map[string]Area // areas keyed by hostname, populated with TimezoneMap
+	Backend     Backend
+	BaseDomain  string     // domain areas are subdomains of, defaults to craigslist.org
+	Parser      Parser     // when nil the parser is detected from each response
+	Selectors   Selectors  // overrides for the html layout, can be changed between searches
+	Categories  []Category // catalog used to validate options, DefaultCategories when nil
+	optionErr   error      // reported by every search, set by ClientOptions that fail to apply
+
+	categoriesMu sync.RWMutex // guards Categories while RefreshCategories replaces it
+
+	// OnLayoutChange is called with the search url whenever a page fails
+	// to parse because of missing markup, before the error is returned.
+	OnLayoutChange func(url string, err *LayoutError)
+}
+
+// ErrNotCraigslistURL is returned for urls that are not on an area of the
+// client's base domain.
+var ErrNotCraigslistURL = errors.New("not a craigslist url")
+
+// ClientOption configures optional Client behavior in NewClient.
+type ClientOption func(*Client)
+
+// WithBackend selects the endpoint GetListings and GetNewListings search against.
+func WithBackend(b Backend) ClientOption {
+	return func(c *Client) {
+		c.Backend = b
+	}
+}
+
+// WithBaseDomain replaces craigslist.org as the domain areas are subdomains
+// of, for test servers and local stand-ins. A port may be included.
+func WithBaseDomain(domain string) ClientOption {
+	return func(c *Client) {
+		c.BaseDomain = domain
+	}
+}
+
+// WithParser forces every response to be parsed by p instead of detecting
+// the parser from the response.
+func WithParser(p Parser) ClientOption {
+	return func(c *Client) {
+		c.Parser = p
+	}
+}
+
+// WithSelectors overrides the css selectors used to parse the html layout.
+// Empty fields keep their DefaultSelectors value. Selectors that fail
+// Validate are returned as the error of every search, before any request.
+func WithSelectors(s Selectors) ClientOption {
+	return func(c *Client) {
+		c.Selectors = s
+		if err := s.Validate(); err != nil {
+			c.optionErr = fmt.Errorf("invalid selectors: %v", err)
+		}
+	}
+}
+
+// WithLayoutHook registers fn as the client's OnLayoutChange hook, useful to
+// alert when craigslist changes its markup.
+func WithLayoutHook(fn func(url string, err *LayoutError)) ClientOption {
+	return func(c *Client) {
+		c.OnLayoutChange = fn
+	}
 }
 
 // Options represents available parameters to construct a URL. Filters
@@ -60,7 +155,7 @@ type Client struct {
 type Options struct {
 	Location          string   // OPTIONAL: defaults to location provided on intialization, providing location here will overrides init value
 	Category          string   // OPTIONAL: defaults to constant defCategory, providing category overrides default variable
-	PostedBy          string   // OPTIONAL: [all, sss], [owner, sso], [dealer, ssq] attention: this only works for default search (sss), not specific categories
+	PostedBy          string   // OPTIONAL: [all, sss], [owner, sso], [dealer, ssq]; other categories use their owner and dealer variants, e.g. cta to cto or ctd
 	SrchType          bool     // OPTIONAL: true or false; dev note - uses "T" or "F" instead of 1 or 0
 	HasPic            bool     // OPTIONAL: true or false; dev note - uses 1 for true, 0 for false
 	PostedToday       bool     // OPTIONAL: true or false; dev note - uses 1 for true, 0 for false
@@ -71,6 +166,21 @@ type Options struct {
 	MaxPrice          string   // OPTIONAL: example = 500
 	Condition         []string // OPTIONAL: [new, 10], [like new, 20], [excellent, 30], [good, 40], [fair, 50], [salvage, 60]
 	Language          []string // OPTIONAL: [af, 1], [ca, 2], [da, 3], [de, 4], [en, 5], [es, 6], [fi, 7], [fr, 8], [it, 9], [nl, 10], [no, 11], [pt, 12], [sv, 13], [tl, 14], [tr, 15], [zh, 16], [ar, 17], [ja, 18], [ko, 19], [ru, 20], [vi, 21]
+
+	Seller         PostedBy       // OPTIONAL: typed alternative to PostedBy, PostedByOwner or PostedByDealer
+	Conditions     []Condition    // OPTIONAL: typed alternative to Condition, both are searched
+	Languages      []Language     // OPTIONAL: typed alternative to Language, both are searched
+	Sort           SortOrder      // OPTIONAL: [relevance, rel], [date, date], [priceasc, priceasc], [pricedsc, pricedsc]; defaults to relevance
+	SearchDistance string         // OPTIONAL: miles from Postal, or Latitude and Longitude; example = 25
+	Postal         string         // OPTIONAL: postal code the distance is measured from; example = 10001
+	Latitude       string         // OPTIONAL: used with Longitude instead of Postal; example = 40.7506
+	Longitude      string         // OPTIONAL: used with Latitude instead of Postal; example = -73.9971
+	Auto           AutoOptions    // OPTIONAL: filters of the cars and trucks categories, see AutoOptions
+	Housing        HousingOptions // OPTIONAL: filters of the housing categories, see HousingOptions
+	Jobs           JobOptions     // OPTIONAL: filters of the jobs and gigs categories, see JobOptions
+	SubArea        string         // OPTIONAL: abbreviation of one of the location's SubAreas, example = brk
+	IncludeNearby  bool           // OPTIONAL: true or false; also search the areas near the location, or only NearbyAreas when provided
+	NearbyAreas    []int          // OPTIONAL: AreaIDs of the nearby areas to include, requires IncludeNearby; example = 59, 355
 }
 
 // Area represents a region according to craigslist.com
@@ -96,34 +206,67 @@ type SubArea struct {
 	SubAreaID        int
 }
 
+// FindSubArea looks up one of the area's SubAreas by its abbreviation.
+func (a Area) FindSubArea(abbreviation string) (SubArea, bool) {
+	for _, subArea := range a.SubAreas {
+		if subArea.Abbreviation == abbreviation {
+			return subArea, true
+		}
+	}
+
+	return SubArea{}, false
+}
+
 // NewClient will instantiate a client, set the location, and return a pointer.
-func NewClient(location string) API {
+func NewClient(location string, opts ...ClientOption) API {
 	c := Client{Location: location, Request: newHTTPService()}
+	for _, opt := range opts {
+		opt(&c)
+	}
 	return &c
 }
 
+func (c *Client) baseDomain() string {
+	if c.BaseDomain == "" {
+		return base
+	}
+	return c.BaseDomain
+}
+
 // FormatURL should be used to programatically construct a URL using a term and Options.
+// Options are encoded as given, PostedBy on a category without an owner or
+// dealer variant searches the whole category. BuildURL reports it instead.
 func (c *Client) FormatURL(term string, options Options) string {
 	finalLocation := options.Location
 	if finalLocation == "" {
 		finalLocation = c.Location
 	}
 
-	finalCategory := options.Category
+	finalCategory := categoryCode(c.categories(), options.Category)
 	if finalCategory == "" {
-		if options.PostedBy == "owner" {
-			finalCategory = defCategoryOwner
-		} else if options.PostedBy == "dealer" {
-			finalCategory = defCategoryDealer
-		} else {
-			finalCategory = defCategory
+		finalCategory = defCategory
+	}
+
+	// owner and dealer searches are separate categories, categories without
+	// the variant are searched as is, see Options.Validate
+	seller := options.postedBy()
+	if seller == PostedByOwner || seller == PostedByDealer {
+		if category, has := categoryByCode(c.categories(), finalCategory); has && category.Variant(seller) != "" {
+			finalCategory = category.Variant(seller)
+		} else if finalCategory == defCategory {
+			finalCategory = seller.Code()
 		}
 	}
 
 	formattedTerm := formatTerm(term)
 
+	searchPath := defPath + finalCategory
+	if options.SubArea != "" {
+		searchPath = defPath + options.SubArea + "/" + finalCategory
+	}
+
 	var url string
-	url = protocol + "://" + finalLocation + "." + base + defPath + finalCategory + "?query=" + formattedTerm + defSort
+	url = protocol + "://" + finalLocation + "." + c.baseDomain() + searchPath + "?query=" + formattedTerm + sortOrder + options.Sort.Code()
 
 	var args string
 	if options.SrchType {
@@ -150,6 +293,16 @@ func (c *Client) FormatURL(term string, options Options) string {
 		args += deliveryAvailable + "1"
 	}
 
+	// craigslist uses 1 for every nearby area and 2 for a selection of them
+	if options.IncludeNearby && len(options.NearbyAreas) > 0 {
+		args += searchNearby + "2"
+		for _, id := range options.NearbyAreas {
+			args += nearbyArea + strconv.Itoa(id)
+		}
+	} else if options.IncludeNearby {
+		args += searchNearby + "1"
+	}
+
 	if options.MinPrice != "" {
 		args += minPrice + options.MinPrice
 	}
@@ -158,80 +311,228 @@ func (c *Client) FormatURL(term string, options Options) string {
 		args += maxPrice + options.MaxPrice
 	}
 
-	conditionMap := map[string]string{"new": "10", "like new": "20", "excellent": "30", "good": "40", "fair": "50", "salvage": "60"}
-	if len(options.Condition) > 0 {
-		for _, c := range options.Condition {
-			url += condition + conditionMap[c]
-		}
+	if options.SearchDistance != "" {
+		args += searchDistance + options.SearchDistance
 	}
 
-	languageMap := map[string]string{"af": "1", "ca": "2", "da": "3", "de": "4", "en": "5", "es": "6", "fi": "7", "fr": "8", "it": "9", "nl": "10", "no": "11", "pt": "12", "sv": "13", "tl": "14", "tr": "15", "zh": "16", "ar": "17", "ja": "18", "ko": "19", "ru": "20", "vi": "21"}
-	if len(options.Language) > 0 {
-		for _, l := range options.Language {
-			url += language + languageMap[l]
-		}
+	if options.Postal != "" {
+		args += postal + queryEscape(options.Postal)
 	}
 
+	if options.Latitude != "" && options.Longitude != "" {
+		args += latitude + queryEscape(options.Latitude) + longitude + queryEscape(options.Longitude)
+	}
+
+	for _, c := range options.conditions() {
+		url += condition + c.Code()
+	}
+
+	for _, l := range options.languages() {
+		url += language + l.Code()
+	}
+
+	args += options.Auto.args()
+	args += options.Housing.args()
+	args += options.Jobs.args()
+
 	url += args
 
 	return url
 }
 
+// BuildURL is FormatURL for options that have not been checked yet, it
+// returns an error wrapping ErrInvalidOptions instead of a url craigslist
+// would silently misread. Categories are checked against the client's
+// catalog and subareas against AreaMap, a SubArea is an error until
+// GetTimezones has loaded it.
+func (c *Client) BuildURL(term string, options Options) (string, error) {
+	err := options.validate(c.categories())
+	if err != nil {
+		return "", err
+	}
+
+	location := options.Location
+	if location == "" {
+		location = c.Location
+	}
+
+	err = c.validateSubArea(location, options.SubArea)
+	if err != nil {
+		return "", err
+	}
+
+	return c.FormatURL(term, options), nil
+}
+
 // GetListings simply takes a URL and returns an iterator containing the first page of listings.
 func (c *Client) GetListings(ctx context.Context, url string) (*Result, error) {
-	resp, err := c.Request.fetch(ctx, url)
+	return c.getListings(ctx, url, nilTime)
+}
+
+// GetNewListings performs the same tasks as GetListings but only
+// returns listings greater than the passed in date. The search is always
+// sorted by date, the cutoff relies on newer listings coming first.
+func (c *Client) GetNewListings(ctx context.Context, url string, date time.Time) (*Result, error) {
+	url, err := withSortOrder(url, SortDate)
 	if err != nil {
-		return nil, fmt.Errorf("error sending http request: %v", err)
+		return nil, fmt.Errorf("unable to parse url: %v", err)
 	}
 
-	if c.TimezoneMap == nil {
-		_, err = c.GetTimezones(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("error getting timezones: %v", err)
-		}
+	return c.getListings(ctx, url, date)
+}
+
+func (c *Client) getListings(ctx context.Context, url string, date time.Time) (*Result, error) {
+	hostname, err := hostnameFromURL(url, c.baseDomain())
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.loadAreas(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting timezones: %v", err)
 	}
-	startHostname := 8
-	endHostname := strings.Index(url, ".")
-	hostname := url[startHostname:endHostname]
 	timezone := c.TimezoneMap[hostname]
 
-	listings, count, err := parseSearchResults(resp.Body)
+	err = c.validateSubArea(hostname, searchSubArea(url))
 	if err != nil {
-		return nil, fmt.Errorf("error parsing search results: %v", err)
+		return nil, err
 	}
 
-	r := newResult(c, url, count, listings, timezone)
+	page, err := c.search(ctx, url, hostname, timezone, date)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newResult(c, url, page.TotalCount, page.Listings, timezone)
+	r.NearbyAreas = page.NearbyAreas
 
 	return r, nil
 }
 
-// GetNewListings performs the same tasks as GetListings but only
-// returns listings greater than the passed in date.
-func (c *Client) GetNewListings(ctx context.Context, url string, date time.Time) (*Result, error) {
-	resp, err := c.Request.fetch(ctx, url)
+// search fetches and parses a single page of results for url from the
+// endpoint selected by the client's Backend. A non zero cutoff stops at the
+// first listing posted before it.
+func (c *Client) search(ctx context.Context, url string, hostname string, timezone string, cutoff time.Time) (*Page, error) {
+	if c.optionErr != nil {
+		return nil, c.optionErr
+	}
+
+	target := url
+	switch c.Backend {
+	case BackendJSON:
+		var err error
+		target, err = jsonSearchURL(url, c.AreaMap, hostname)
+		if err != nil {
+			return nil, fmt.Errorf("error building json search url: %v", err)
+		}
+	case BackendRSS:
+		var err error
+		target, err = rssSearchURL(url)
+		if err != nil {
+			return nil, fmt.Errorf("error building rss search url: %v", err)
+		}
+	}
+
+	resp, err := c.Request.fetch(ctx, target)
 	if err != nil {
 		return nil, fmt.Errorf("error sending http request: %v", err)
 	}
+	defer resp.Body.Close()
 
-	if c.TimezoneMap == nil {
-		_, err = c.GetTimezones(ctx)
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the http body: %v", err)
+	}
+
+	// xml declares its own charset which the rss parser handles
+	contentType := resp.Header.Get("Content-Type")
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("<?xml")) {
+		data, err = toUTF8(data, detectCharset(contentType, data))
 		if err != nil {
-			return nil, fmt.Errorf("error getting timezones: %v", err)
+			return nil, fmt.Errorf("error decoding the http body: %v", err)
 		}
 	}
-	startHostname := 8
-	endHostname := strings.Index(url, ".")
-	hostname := url[startHostname:endHostname]
-	timezone := c.TimezoneMap[hostname]
 
-	listings, count, err := parseSearchResultsAfter(resp.Body, date)
+	parser := c.Parser
+	if parser == nil {
+		parser = DetectParser(contentType, data)
+	}
+
+	loc, err := time.LoadLocation(timezone)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing search results: %v", err)
+		loc = time.UTC
 	}
 
-	r := newResult(c, url, count, listings, timezone)
+	page, err := parser.Parse(bytes.NewReader(data), ParseOptions{
+		Cutoff:     cutoff,
+		Category:   searchCategory(url),
+		Location:   loc,
+		Selectors:  c.Selectors,
+		BaseDomain: c.BaseDomain,
+	})
+	if err != nil {
+		var layoutErr *LayoutError
+		if errors.As(err, &layoutErr) && c.OnLayoutChange != nil {
+			c.OnLayoutChange(url, layoutErr)
+		}
+		return nil, fmt.Errorf("error parsing search results: %w", err)
+	}
 
-	return r, nil
+	tagNearby(page.Listings, hostname)
+	c.resolveSubAreas(page.Listings, hostname, searchSubArea(url))
+
+	return page, nil
+}
+
+// resolveSubAreas points each listing's SubArea at the matching entry of the
+// listing's area, defaulting to the searched hostname. Listings of a search
+// scoped to a subarea are tagged with it when their link does not name one.
+func (c *Client) resolveSubAreas(listings []Listing, hostname string, searched string) {
+	for i := range listings {
+		if listings[i].SubAreaAbbr == "" && searched != "" {
+			listings[i].SubAreaAbbr = searched
+			if listings[i].Area == "" {
+				listings[i].Area = hostname
+			}
+		}
+
+		if listings[i].SubAreaAbbr == "" {
+			continue
+		}
+
+		areaHostname := listings[i].Area
+		if areaHostname == "" {
+			areaHostname = hostname
+		}
+
+		area, has := c.AreaMap[areaHostname]
+		if !has {
+			continue
+		}
+
+		if subArea, has := area.FindSubArea(listings[i].SubAreaAbbr); has {
+			listings[i].SubArea = &subArea
+		}
+	}
+}
+
+// loadAreas runs GetTimezones unless both TimezoneMap and AreaMap are set.
+// A TimezoneMap set by the caller is kept, only AreaMap is filled in for it.
+func (c *Client) loadAreas(ctx context.Context) error {
+	if c.TimezoneMap != nil && c.AreaMap != nil {
+		return nil
+	}
+
+	preset := c.TimezoneMap
+	_, err := c.GetTimezones(ctx)
+	if err != nil {
+		return err
+	}
+
+	if preset != nil {
+		c.TimezoneMap = preset
+	}
+	return nil
 }
 
 // GetTimezones fetches and populates TimezoneMap
@@ -250,15 +551,85 @@ func (c *Client) GetTimezones(ctx context.Context) (map[string]string, error) {
 	err = json.Unmarshal(data, &areas)
 
 	timezones := make(map[string]string)
+	areaMap := make(map[string]Area)
 	for _, area := range areas {
 		timezones[area.Hostname] = area.Timezone
+		areaMap[area.Hostname] = area
 	}
 
 	c.TimezoneMap = timezones
+	c.AreaMap = areaMap
 
 	return timezones, nil
 }
 
+// validateSubArea checks the area of hostname has the subarea, which needs
+// the areas loaded by GetTimezones. Areas missing from them, or that list no
+// subareas at all, are not checked.
+func (c *Client) validateSubArea(hostname string, subArea string) error {
+	if subArea == "" {
+		return nil
+	}
+
+	if c.AreaMap == nil {
+		return fmt.Errorf("unable to validate subarea %q: areas are not loaded, call GetTimezones first", subArea)
+	}
+
+	area, has := c.AreaMap[hostname]
+	if !has || len(area.SubAreas) == 0 {
+		return nil
+	}
+
+	if _, has := area.FindSubArea(subArea); !has {
+		return fmt.Errorf("%w: unknown subarea %q of %s", ErrInvalidOptions, subArea, hostname)
+	}
+
+	return nil
+}
+
+// withSortOrder replaces the sort order of a search url.
+func withSortOrder(searchURL string, order SortOrder) (string, error) {
+	u, err := url.Parse(searchURL)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set("sort", order.Code())
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// hostnameFromURL returns the area hostname (newyork) of a url on baseDomain.
+// Both http and https are accepted, as are urls with a subarea, a port or any
+// path since only the host is read.
+func hostnameFromURL(rawURL string, baseDomain string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse url: %v", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%w: %s", ErrNotCraigslistURL, rawURL)
+	}
+
+	host := strings.ToLower(u.Host)
+	suffix := "." + strings.ToLower(baseDomain)
+	hostname := strings.TrimSuffix(host, suffix)
+	if hostname == host || hostname == "" || strings.Contains(hostname, ".") {
+		return "", fmt.Errorf("%w: %s", ErrNotCraigslistURL, rawURL)
+	}
+
+	return hostname, nil
+}
+
+// queryEscape escapes a free form option value, FormatURL shadows the url
+// package.
+func queryEscape(value string) string {
+	return url.QueryEscape(value)
+}
+
 func formatTerm(term string) string {
 	pieces := strings.Split(term, " ")
 
@@ -1,8 +1,11 @@
 package gocraigslist
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -18,6 +21,9 @@ type mockFetcher struct {
 	callCount    int
 	data         []byte
 	timezoneData Area
+	lastURL      string
+	routes       map[string][]byte // served instead of data when the url contains the key, longest key wins
+	contentType  string            // sniffed from the data when empty
 }
 
 func (m *mockFetcher) fetch(ctx context.Context, url string) (*http.Response, error) {
@@ -38,6 +44,24 @@ func (m *mockFetcher) fetch(ctx context.Context, url string) (*http.Response, er
 				ShortDescription: "SF bay area",
 				Timezone:         "America/Los_Angeles",
 			},
+			{
+				Abbreviation:     "nyc",
+				AreaID:           3,
+				Country:          "US",
+				Description:      "new york city",
+				Hostname:         "newyork",
+				Latitude:         40.7142,
+				Longitude:        -74.0064,
+				Region:           "NY",
+				ShortDescription: "new york",
+				Timezone:         "America/New_York",
+				SubAreas: []SubArea{
+					{Abbreviation: "mnh", Description: "manhattan", ShortDescription: "manhattan", SubAreaID: 1},
+					{Abbreviation: "brk", Description: "brooklyn", ShortDescription: "brooklyn", SubAreaID: 2},
+					{Abbreviation: "que", Description: "queens", ShortDescription: "queens", SubAreaID: 3},
+					{Abbreviation: "brx", Description: "bronx", ShortDescription: "bronx", SubAreaID: 4},
+				},
+			},
 		}
 		data, err := json.Marshal(fakeArea)
 		if err != nil {
@@ -47,9 +71,26 @@ func (m *mockFetcher) fetch(ctx context.Context, url string) (*http.Response, er
 		return res.Result(), nil
 	}
 
+	m.lastURL = url
+	if m.contentType != "" {
+		res.Header().Set("Content-Type", m.contentType)
+	}
+
 	// some logic so the test doesnt need to keep reading the file
 	var data []byte
 	var err error
+	route := ""
+	for key := range m.routes {
+		if strings.Contains(url, key) && len(key) > len(route) {
+			route = key
+		}
+	}
+	if route != "" {
+		m.callCount++
+		res.Write(m.routes[route])
+		return res.Result(), nil
+	}
+
 	if len(m.data) == 0 {
 		data, err = ioutil.ReadFile("./test.html")
 		if err != nil {
@@ -67,6 +108,27 @@ func (m *mockFetcher) fetch(ctx context.Context, url string) (*http.Response, er
 
 }
 
+type stubParser struct {
+	called  bool
+	options ParseOptions
+}
+
+func (p *stubParser) Parse(data io.Reader, options ParseOptions) (*Page, error) {
+	p.called = true
+	p.options = options
+	return &Page{Listings: []Listing{}}, nil
+}
+
+// fixedParser returns the same listings for every page.
+type fixedParser struct {
+	listings []Listing
+}
+
+func (p *fixedParser) Parse(data io.Reader, options ParseOptions) (*Page, error) {
+	listings := append([]Listing{}, p.listings...)
+	return &Page{Listings: listings, TotalCount: len(listings)}, nil
+}
+
 func TestFormatURL(t *testing.T) {
 	client := NewClient("newyork")
 
@@ -89,6 +151,22 @@ func TestFormatURL(t *testing.T) {
 		assert.Equal(t, expected, url)
 	})
 
+	t.Run("should scope the search to a subarea", func(t *testing.T) {
+		url := client.FormatURL("xbox", Options{SubArea: "brk"})
+		assert.Equal(t, "https://newyork.craigslist.org/search/brk/sss?query=xbox&sort=rel", url)
+
+		url = client.FormatURL("civic", Options{SubArea: "eby", Location: "sfbay", Category: "cta", Seller: PostedByOwner})
+		assert.Equal(t, "https://sfbay.craigslist.org/search/eby/cto?query=civic&sort=rel", url)
+	})
+
+	t.Run("should include nearby areas", func(t *testing.T) {
+		url := client.FormatURL("xbox", Options{IncludeNearby: true})
+		assert.Equal(t, "https://newyork.craigslist.org/search/sss?query=xbox&sort=rel&searchNearby=1", url)
+
+		url = client.FormatURL("xbox", Options{IncludeNearby: true, NearbyAreas: []int{59, 355}})
+		assert.Equal(t, "https://newyork.craigslist.org/search/sss?query=xbox&sort=rel&searchNearby=2&nearbyArea=59&nearbyArea=355", url)
+	})
+
 	t.Run("should overwright category if provided by options", func(t *testing.T) {
 		o := Options{Category: "aaa"}
 		expected := "https://newyork.craigslist.org/search/aaa?query=xbox&sort=rel"
@@ -97,6 +175,26 @@ func TestFormatURL(t *testing.T) {
 		assert.Equal(t, expected, url)
 	})
 
+	t.Run("should map posted by onto the category variant", func(t *testing.T) {
+		for _, test := range []struct {
+			given    Options
+			expected string
+		}{
+			{given: Options{PostedBy: "owner"}, expected: "sso"},
+			{given: Options{Category: "sss", PostedBy: "dealer"}, expected: "ssq"},
+			{given: Options{Category: "cta", Seller: PostedByOwner}, expected: "cto"},
+			{given: Options{Category: "cta", Seller: PostedByDealer}, expected: "ctd"},
+			{given: Options{Category: "cto", PostedBy: "ssq"}, expected: "ctd"},
+			{given: Options{Category: "boo", Seller: PostedByOwner}, expected: "boa"},
+			{given: Options{Category: "cta", Seller: PostedByAll}, expected: "cta"},
+			// no variant, searched as is and left for BuildURL to reject
+			{given: Options{Category: "apa", PostedBy: "owner"}, expected: "apa"},
+		} {
+			expected := "https://newyork.craigslist.org/search/" + test.expected + "?query=xbox&sort=rel"
+			assert.Equal(t, expected, client.FormatURL("xbox", test.given))
+		}
+	})
+
 	t.Run("should account for bool options", func(t *testing.T) {
 		o := Options{
 			SrchType:          true,
@@ -218,6 +316,107 @@ func TestFormatURL(t *testing.T) {
 		}
 	})
 
+	t.Run("accounts for typed conditions and languages", func(t *testing.T) {
+		url := client.FormatURL("xbox", Options{
+			Condition:  []string{"new"},
+			Conditions: []Condition{ConditionLikeNew},
+			Language:   []string{"en"},
+			Languages:  []Language{LanguageJA},
+		})
+		assert.Equal(t, "https://newyork.craigslist.org/search/sss?query=xbox&sort=rel&condition=10&condition=20&language=5&language=18", url)
+	})
+
+	t.Run("accounts for search distance", func(t *testing.T) {
+		url := client.FormatURL("xbox", Options{SearchDistance: "25", Postal: "10001"})
+		assert.Equal(t, "https://newyork.craigslist.org/search/sss?query=xbox&sort=rel&search_distance=25&postal=10001", url)
+
+		url = client.FormatURL("xbox", Options{SearchDistance: "5", Latitude: "40.7506", Longitude: "-73.9971"})
+		assert.Equal(t, "https://newyork.craigslist.org/search/sss?query=xbox&sort=rel&search_distance=5&lat=40.7506&lon=-73.9971", url)
+
+		// free form values cannot break out of their parameter
+		url = client.FormatURL("xbox", Options{SearchDistance: "5", Postal: "SW1A 1AA&x=1", Latitude: "1&s=1", Longitude: "2#"})
+		assert.Equal(t, "https://newyork.craigslist.org/search/sss?query=xbox&sort=rel&search_distance=5&postal=SW1A+1AA%26x%3D1&lat=1%26s%3D1&lon=2%23", url)
+	})
+
+	t.Run("accounts for auto options", func(t *testing.T) {
+		o := Options{
+			Category: "cta",
+			Auto: AutoOptions{
+				MakeModel:    "honda civic",
+				MinYear:      "2010",
+				MaxYear:      "2015",
+				MaxMiles:     "90000",
+				TitleStatus:  []TitleStatus{TitleClean},
+				Transmission: []Transmission{TransmissionManual},
+				FuelType:     []FuelType{FuelGas, FuelHybrid},
+				Drivetrain:   []Drivetrain{DrivetrainFWD},
+				Cylinders:    []Cylinders{Cylinders4},
+				BodyType:     []BodyType{BodySedan, BodyCoupe},
+				PaintColor:   []PaintColor{PaintBrown},
+			},
+		}
+
+		expected := "https://newyork.craigslist.org/search/cta?query=civic&sort=rel" +
+			"&auto_make_model=honda+civic&min_auto_year=2010&max_auto_year=2015&max_auto_miles=90000" +
+			"&auto_title_status=1&auto_transmission=1&auto_fuel_type=1&auto_fuel_type=3&auto_drivetrain=1" +
+			"&auto_cylinders=2&auto_bodytype=8&auto_bodytype=3&auto_paint=20"
+		assert.Equal(t, expected, client.FormatURL("civic", o))
+	})
+
+	t.Run("accounts for housing options", func(t *testing.T) {
+		o := Options{
+			Category: "apa",
+			Housing: HousingOptions{
+				MinBedrooms:  "1",
+				MaxBedrooms:  "2",
+				MinBathrooms: "1",
+				MinSqft:      "500",
+				CatsOK:       true,
+				NoSmoking:    true,
+				Laundry:      []Laundry{LaundryInUnit, LaundryHookups},
+				Parking:      []Parking{ParkingAttachedGarage},
+				Availability: AvailableWithin30Days,
+			},
+		}
+
+		expected := "https://newyork.craigslist.org/search/apa?query=loft&sort=rel" +
+			"&min_bedrooms=1&max_bedrooms=2&min_bathrooms=1&minSqft=500&pets_cat=1&no_smoking=1" +
+			"&laundry=1&laundry=4&parking=2&availabilityMode=1"
+		assert.Equal(t, expected, client.FormatURL("loft", o))
+	})
+
+	t.Run("accounts for job options", func(t *testing.T) {
+		o := Options{
+			Category: "ggg",
+			Jobs: JobOptions{
+				EmploymentType: []EmploymentType{EmploymentPartTime, EmploymentContract},
+				Telecommuting:  true,
+				Nonprofit:      true,
+				Pay:            GigPaid,
+			},
+		}
+
+		expected := "https://newyork.craigslist.org/search/ggg?query=writer&sort=rel" +
+			"&employment_type=2&employment_type=3&is_telecommuting=1&is_nonprofit=1&is_paid=yes"
+		assert.Equal(t, expected, client.FormatURL("writer", o))
+	})
+
+	t.Run("accounts for sort order", func(t *testing.T) {
+		for _, test := range []struct {
+			given    SortOrder
+			expected string
+		}{
+			{given: "", expected: "https://newyork.craigslist.org/search/sss?query=xbox&sort=rel"},
+			{given: SortRelevance, expected: "https://newyork.craigslist.org/search/sss?query=xbox&sort=rel"},
+			{given: SortDate, expected: "https://newyork.craigslist.org/search/sss?query=xbox&sort=date"},
+			{given: SortPriceAsc, expected: "https://newyork.craigslist.org/search/sss?query=xbox&sort=priceasc"},
+			{given: SortPriceDesc, expected: "https://newyork.craigslist.org/search/sss?query=xbox&sort=pricedsc"},
+		} {
+			url := client.FormatURL("xbox", Options{Sort: test.given})
+			assert.Equal(t, test.expected, url)
+		}
+	})
+
 	t.Run("accounts for languages", func(t *testing.T) {
 		languageMap := map[string]string{"af": "1", "ca": "2", "da": "3", "de": "4", "en": "5", "es": "6", "fi": "7", "fr": "8", "it": "9", "nl": "10", "no": "11", "pt": "12", "sv": "13", "tl": "14", "tr": "15", "zh": "16", "ar": "17", "ja": "18", "ko": "19", "ru": "20", "vi": "21"}
 
@@ -244,8 +443,157 @@ func TestFormatURL(t *testing.T) {
 	})
 }
 
+func TestParseSearchURL(t *testing.T) {
+	client := NewClient("newyork").(*Client)
+
+	t.Run("should round trip with FormatURL", func(t *testing.T) {
+		for _, test := range []struct {
+			term    string
+			options Options
+		}{
+			{term: "xbox", options: Options{Location: "newyork"}},
+			{term: "xbox 123 . #$%", options: Options{Location: "sfbay", Category: "ata"}},
+			{term: "civic", options: Options{Location: "newyork", PostedBy: "owner"}},
+			{term: "civic", options: Options{Location: "newyork", PostedBy: "dealer"}},
+			{term: "stoop", options: Options{Location: "newyork", SubArea: "brk", Category: "zip"}},
+			{term: "couch", options: Options{Location: "newyork", IncludeNearby: true}},
+			{term: "couch", options: Options{Location: "newyork", IncludeNearby: true, NearbyAreas: []int{59, 355, 4}}},
+			{term: "civic", options: Options{Location: "sfbay", SubArea: "eby", Category: "cta", PostedBy: "dealer"}},
+			{term: "desk", options: Options{Location: "newyork", SearchDistance: "5", Latitude: "40.7506", Longitude: "-73.9971"}},
+			{
+				term: "civic",
+				options: Options{
+					Location: "newyork",
+					Category: "cta",
+					PostedBy: "owner",
+					Auto: AutoOptions{
+						MakeModel:    "honda civic",
+						MinYear:      "2010",
+						MinMiles:     "1000",
+						MaxMiles:     "90000",
+						TitleStatus:  []TitleStatus{TitleClean, TitleRebuilt},
+						Transmission: []Transmission{TransmissionAutomatic},
+						FuelType:     []FuelType{FuelElectric},
+						Drivetrain:   []Drivetrain{Drivetrain4WD},
+						Cylinders:    []Cylinders{CylindersOther},
+						BodyType:     []BodyType{BodySUV},
+						PaintColor:   []PaintColor{PaintBlack, PaintCustom},
+					},
+				},
+			},
+			{
+				term: "loft",
+				options: Options{
+					Location: "newyork",
+					Category: "roo",
+					Housing: HousingOptions{
+						MinBedrooms:  "1",
+						MaxBedrooms:  "3",
+						MinBathrooms: "1",
+						MaxBathrooms: "2",
+						MinSqft:      "500",
+						MaxSqft:      "1200",
+						CatsOK:       true,
+						DogsOK:       true,
+						Furnished:    true,
+						NoSmoking:    true,
+						Wheelchair:   true,
+						Laundry:      []Laundry{LaundryOnSite},
+						Parking:      []Parking{ParkingStreet, ParkingNone},
+						Availability: AvailableBeyond30Days,
+					},
+				},
+			},
+			{
+				term: "cook",
+				options: Options{
+					Location: "newyork",
+					Category: "jjj",
+					Jobs: JobOptions{
+						EmploymentType: []EmploymentType{EmploymentFullTime, EmploymentEmployeeChoice},
+						Telecommuting:  true,
+						Internship:     true,
+						Nonprofit:      true,
+						Pay:            GigUnpaid,
+					},
+				},
+			},
+			{
+				term: "lamp",
+				options: Options{
+					Location:          "newyork",
+					SrchType:          true,
+					HasPic:            true,
+					PostedToday:       true,
+					BundleDuplicates:  true,
+					CryptoCurrencyOK:  true,
+					DeliveryAvailable: true,
+					MinPrice:          "100",
+					MaxPrice:          "500",
+					Sort:              SortPriceAsc,
+					SearchDistance:    "25",
+					Postal:            "M5V 2T6",
+					Condition:         []string{"new", "like new", "salvage"},
+					Language:          []string{"en", "ja", "vi"},
+				},
+			},
+		} {
+			url := client.FormatURL(test.term, test.options)
+
+			term, options, err := client.ParseSearchURL(url)
+			assert.NoError(t, err, url)
+			assert.Equal(t, test.term, term, url)
+			assert.Equal(t, test.options, options, url)
+			assert.Equal(t, url, client.FormatURL(term, options))
+		}
+	})
+
+	t.Run("should read owner and dealer variants as PostedBy", func(t *testing.T) {
+		for _, test := range []struct {
+			given    string
+			expected Options
+		}{
+			{given: "https://newyork.craigslist.org/search/sso", expected: Options{Location: "newyork", PostedBy: "owner"}},
+			{given: "https://newyork.craigslist.org/search/ctd", expected: Options{Location: "newyork", Category: "cta", PostedBy: "dealer"}},
+			{given: "https://newyork.craigslist.org/search/mcy", expected: Options{Location: "newyork", Category: "mca", PostedBy: "owner"}},
+			{given: "https://newyork.craigslist.org/search/apa", expected: Options{Location: "newyork", Category: "apa"}},
+		} {
+			_, options, err := client.ParseSearchURL(test.given)
+			assert.NoError(t, err, test.given)
+			assert.Equal(t, test.expected, options, test.given)
+		}
+	})
+
+	t.Run("should parse urls copied from a browser", func(t *testing.T) {
+		term, options, err := client.ParseSearchURL("https://sfbay.craigslist.org/d/antiques/search/ata?query=oak%20table&hasPic=1#search=1~gallery~0~0")
+		assert.NoError(t, err)
+		assert.Equal(t, "oak table", term)
+		assert.Equal(t, Options{Location: "sfbay", Category: "ata", HasPic: true}, options)
+	})
+
+	t.Run("should reject urls it cannot parse", func(t *testing.T) {
+		for _, given := range []string{
+			"https://newyork.example.com/search/sss?query=xbox",
+			"https://newyork.craigslist.org/about/help",
+			"https://newyork.craigslist.org/search/?query=xbox",
+			"https://newyork.craigslist.org/search/brk/zip/extra?query=xbox",
+			"https://newyork.craigslist.org/search/sss?searchNearby=2&nearbyArea=alb",
+			"https://newyork.craigslist.org/search/sss?searchNearby=2",
+			"https://newyork.craigslist.org/search/sss?condition=99",
+			"https://newyork.craigslist.org/search/sss?language=99",
+			"https://newyork.craigslist.org/search/cta?auto_paint=99",
+			"https://newyork.craigslist.org/search/apa?laundry=99",
+			"https://newyork.craigslist.org/search/apa?availabilityMode=9",
+			"https://newyork.craigslist.org/search/jjj?employment_type=9",
+		} {
+			_, _, err := client.ParseSearchURL(given)
+			assert.Error(t, err, given)
+		}
+	})
+}
+
 func TestResultIterator(t *testing.T) {
-	client := Client{Location: "newyork", Request: &mockFetcher{}}
+	client := Client{Location: "newyork", Request: &mockFetcher{}, BaseDomain: "fakeurl.com"}
 
 	t.Run("should set done correctly", func(t *testing.T) {
 		loc, err := time.LoadLocation("America/Los_Angeles")
@@ -266,6 +614,15 @@ func TestResultIterator(t *testing.T) {
 		assert.True(t, result.Done)
 	})
 
+	t.Run("GetNewListings should always sort by date", func(t *testing.T) {
+		m := &mockFetcher{}
+		client := Client{Location: "newyork", Request: m, BaseDomain: "fakeurl.com"}
+
+		_, err := client.GetNewListings(context.Background(), "https://sfbay.fakeurl.com/search/sss?query=xbox&sort=priceasc", time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, "https://sfbay.fakeurl.com/search/sss?query=xbox&sort=date", m.lastURL)
+	})
+
 	t.Run("test iterator functionality surrounding pagination", func(t *testing.T) {
 		result, err := client.GetListings(context.Background(), "https://sfbay.fakeurl.com")
 		assert.NoError(t, err)
@@ -321,6 +678,587 @@ func TestResultIterator(t *testing.T) {
 	})
 }
 
+func TestJobListing(t *testing.T) {
+	data, err := ioutil.ReadFile("./test_job.html")
+	assert.NoError(t, err)
+
+	t.Run("should fill in compensation and employment type from the posting", func(t *testing.T) {
+		link := "https://newyork.craigslist.org/brk/fbh/d/brooklyn-line-cook-full-time/7138200001.html"
+		m := &mockFetcher{routes: map[string][]byte{link: data}}
+		client := Client{Location: "newyork", Request: m}
+
+		listing := Listing{DataPID: "7138200001", Title: "Line Cook - Full Time", Link: link}
+		job, err := client.GetJobListing(context.Background(), listing)
+		assert.NoError(t, err)
+		assert.Equal(t, link, m.lastURL)
+		assert.Equal(t, listing, job.Listing)
+		assert.Equal(t, "$18 - $22/hr plus tips", job.Compensation)
+		assert.Equal(t, EmploymentFullTime, job.EmploymentType)
+	})
+
+	t.Run("should read the newer attribute layout", func(t *testing.T) {
+		page := `<div class="attrgroup">
+			<div class="attr"><span class="labl">compensation:</span><span class="valu">pay depends on experience</span></div>
+			<div class="attr"><span class="labl">employment type:</span><span class="valu">part-time</span></div>
+		</div>`
+
+		job, err := parseJobPosting(strings.NewReader(page))
+		assert.NoError(t, err)
+		assert.Equal(t, "pay depends on experience", job.Compensation)
+		assert.Equal(t, EmploymentPartTime, job.EmploymentType)
+	})
+
+	t.Run("should keep an unknown employment type as written", func(t *testing.T) {
+		page := `<p class="attrgroup"><span>employment type: <b>seasonal</b></span></p>`
+
+		job, err := parseJobPosting(strings.NewReader(page))
+		assert.NoError(t, err)
+		assert.Equal(t, EmploymentType("seasonal"), job.EmploymentType)
+	})
+
+	t.Run("should require a link", func(t *testing.T) {
+		client := Client{Location: "newyork", Request: &mockFetcher{}}
+
+		_, err := client.GetJobListing(context.Background(), Listing{DataPID: "7138200001"})
+		assert.Error(t, err)
+	})
+}
+
+func TestJSONBackend(t *testing.T) {
+	data, err := ioutil.ReadFile("./test_search.json")
+	assert.NoError(t, err)
+
+	t.Run("should request the json api and decode listings", func(t *testing.T) {
+		m := &mockFetcher{data: data}
+		client := Client{Location: "newyork", Request: m, Backend: BackendJSON}
+
+		result, err := client.GetListings(context.Background(), "https://sfbay.craigslist.org/search/atq?query=statue&sort=rel")
+		assert.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(m.lastURL, jsonAPIURL+"?"))
+		assert.Contains(t, m.lastURL, "batch=1-0-120-0-0")
+		assert.Contains(t, m.lastURL, "searchPath=atq")
+		assert.Contains(t, m.lastURL, "query=statue")
+
+		assert.Equal(t, 3, result.TotalCount)
+		assert.True(t, result.Done)
+		assert.Len(t, result.Listings, 3)
+
+		first := result.Listings[0]
+		assert.Equal(t, "7132606866", first.DataPID)
+		assert.Equal(t, "2020-06-08 14:45:12", first.Date)
+		assert.Equal(t, "Vintage Electric Tomato Strainer Machine", first.Title)
+		assert.Equal(t, "https://newyork.craigslist.org/brx/atq/d/bronx-vintage-electric-tomato-strainer/7132606866.html", first.Link)
+		assert.Equal(t, "$250", first.Price)
+		assert.Equal(t, 40.8262, first.Latitude)
+		assert.Equal(t, -73.8196, first.Longitude)
+		assert.Equal(t, []string{"00a0a_ggtoxMsCVLk_0CI0t2", "00I0I_b4K06zpCyod_0t20lM"}, first.ImageIDs)
+
+		last := result.Listings[2]
+		assert.Equal(t, "7128655269", last.DataPID)
+		assert.Equal(t, "", last.Price)
+		assert.Empty(t, last.ImageIDs)
+	})
+
+	t.Run("should stop at the cutoff date", func(t *testing.T) {
+		client := Client{Location: "newyork", Request: &mockFetcher{data: data}, Backend: BackendJSON}
+
+		loc, err := time.LoadLocation("America/Los_Angeles")
+		assert.NoError(t, err)
+		cutoff := time.Date(2020, 6, 8, 14, 40, 0, 0, loc)
+
+		result, err := client.GetNewListings(context.Background(), "https://sfbay.craigslist.org/search/atq?query=statue&sort=rel", cutoff)
+		assert.NoError(t, err)
+		assert.Len(t, result.Listings, 1)
+	})
+
+	t.Run("should link to the client's base domain", func(t *testing.T) {
+		client := Client{Location: "newyork", Request: &mockFetcher{data: data}, Backend: BackendJSON, BaseDomain: "localhost:8080"}
+
+		result, err := client.GetListings(context.Background(), "https://sfbay.localhost:8080/search/atq?query=statue&sort=rel")
+		assert.NoError(t, err)
+
+		first := result.Listings[0]
+		assert.Equal(t, "https://newyork.localhost:8080/brx/atq/d/bronx-vintage-electric-tomato-strainer/7132606866.html", first.Link)
+		assert.Equal(t, first.Link, first.Ref().URL())
+	})
+
+	t.Run("should tag listings without a link by their location", func(t *testing.T) {
+		nearby := bytes.Replace(data, []byte(`[3, "newyork", "brk"]`), []byte(`[349, "newjersey", "jsy"]`), 1)
+		nearby = bytes.Replace(nearby, []byte(`[6, "brooklyn-backgammon-game-table-antique"], `), nil, 1)
+		client := Client{Location: "newyork", Request: &mockFetcher{data: nearby}, Backend: BackendJSON}
+
+		result, err := client.GetListings(context.Background(), "https://newyork.craigslist.org/search/atq?query=statue&searchNearby=1")
+		assert.NoError(t, err)
+
+		assert.False(t, result.Listings[0].Nearby)
+		last := result.Listings[2]
+		assert.Equal(t, "", last.Link)
+		assert.Equal(t, "newjersey", last.Area)
+		assert.Equal(t, "jsy", last.SubAreaAbbr)
+		assert.True(t, last.Nearby)
+	})
+
+	t.Run("should request the next batch for the next page", func(t *testing.T) {
+		m := &mockFetcher{data: data}
+		client := Client{Location: "newyork", Request: m, Backend: BackendJSON}
+
+		result, err := client.GetListings(context.Background(), "https://sfbay.craigslist.org/search/atq?query=statue&sort=rel")
+		assert.NoError(t, err)
+
+		result.Next(context.Background(), time.Time{})
+		assert.Contains(t, m.lastURL, "batch=1-120-120-0-0")
+		assert.NotContains(t, m.lastURL, "&s=")
+	})
+
+	t.Run("should error for an area without an id", func(t *testing.T) {
+		m := &mockFetcher{data: data}
+		client := Client{Location: "newyork", Request: m, Backend: BackendJSON}
+
+		_, err := client.GetListings(context.Background(), "https://boston.craigslist.org/search/atq?query=statue")
+		assert.Error(t, err)
+		assert.NotContains(t, m.lastURL, jsonAPIURL)
+	})
+}
+
+func TestRSSBackend(t *testing.T) {
+	data, err := ioutil.ReadFile("./test_search.rss")
+	assert.NoError(t, err)
+
+	t.Run("should request the rss feed and decode listings", func(t *testing.T) {
+		m := &mockFetcher{data: data}
+		client := Client{Location: "newyork", Request: m, Backend: BackendRSS}
+
+		url := client.FormatURL("antique", Options{Category: "atq"})
+		result, err := client.GetListings(context.Background(), url)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "https://newyork.craigslist.org/search/atq?format=rss&query=antique&sort=rel", m.lastURL)
+
+		assert.Equal(t, 3, result.TotalCount)
+		assert.True(t, result.Done)
+		assert.Len(t, result.Listings, 3)
+
+		first := result.Listings[0]
+		assert.Equal(t, "7132606866", first.DataPID)
+		assert.Equal(t, "2020-06-08 14:45:12", first.Date)
+		assert.Equal(t, "Vintage Electric Tomato Strainer Machine", first.Title)
+		assert.Equal(t, "$250", first.Price)
+		assert.Equal(t, "https://newyork.craigslist.org/brx/atq/d/bronx-vintage-electric-tomato-strainer/7132606866.html", first.Link)
+		assert.Equal(t, "Vintage electric tomato strainer, works great & comes with all attachments. [...]", first.Description)
+		assert.Equal(t, []string{"00a0a_ggtoxMsCVLk_0CI0t2"}, first.ImageIDs)
+
+		last := result.Listings[2]
+		assert.Equal(t, "Backgammon Game Table Antique", last.Title)
+		assert.Equal(t, "", last.Price)
+		assert.Empty(t, last.ImageIDs)
+	})
+
+	t.Run("should stop at the cutoff date", func(t *testing.T) {
+		client := Client{Location: "newyork", Request: &mockFetcher{data: data}, Backend: BackendRSS}
+
+		loc, err := time.LoadLocation("America/New_York")
+		assert.NoError(t, err)
+		cutoff := time.Date(2020, 6, 8, 14, 40, 0, 0, loc)
+
+		url := client.FormatURL("antique", Options{Category: "atq"})
+		result, err := client.GetNewListings(context.Background(), url, cutoff)
+		assert.NoError(t, err)
+		assert.Len(t, result.Listings, 2)
+	})
+
+	t.Run("should tag listings without an area in their link by their source", func(t *testing.T) {
+		link := "https://newyork.craigslist.org/brk/atq/d/brooklyn-backgammon-game-table-antique/7128655269.html"
+		nearby := bytes.Replace(data, []byte("<link>"+link), []byte("<link>/brk/atq/d/brooklyn-backgammon-game-table-antique/7128655269.html"), 1)
+		nearby = bytes.Replace(nearby, []byte("<dc:source>"+link), []byte("<dc:source>https://newjersey.craigslist.org/atq/d/backgammon-game-table/7128655269.html"), 1)
+		client := Client{Location: "newyork", Request: &mockFetcher{data: nearby}, Backend: BackendRSS}
+
+		result, err := client.GetListings(context.Background(), "https://newyork.craigslist.org/search/atq?query=antique&searchNearby=1")
+		assert.NoError(t, err)
+
+		assert.False(t, result.Listings[0].Nearby)
+		last := result.Listings[2]
+		assert.Equal(t, "newjersey", last.Area)
+		assert.True(t, last.Nearby)
+	})
+}
+
+func TestParserSelection(t *testing.T) {
+	data, err := ioutil.ReadFile("./test_static.html")
+	assert.NoError(t, err)
+
+	t.Run("should detect the layout of the response", func(t *testing.T) {
+		client := Client{Location: "newyork", Request: &mockFetcher{data: data}}
+
+		result, err := client.GetListings(context.Background(), "https://sfbay.craigslist.org/search/atq?query=antique&sort=rel")
+		assert.NoError(t, err)
+		assert.Len(t, result.Listings, 3)
+		assert.Equal(t, 3, result.TotalCount)
+	})
+
+	t.Run("should use the parser provided on the client", func(t *testing.T) {
+		p := &stubParser{}
+		client := NewClient("newyork", WithParser(p)).(*Client)
+		client.Request = &mockFetcher{data: data}
+
+		result, err := client.GetListings(context.Background(), "https://sfbay.craigslist.org/search/atq?query=antique&sort=rel")
+		assert.NoError(t, err)
+		assert.True(t, p.called)
+		assert.Equal(t, "atq", p.options.Category)
+		assert.Len(t, result.Listings, 0)
+	})
+}
+
+func TestLayoutHook(t *testing.T) {
+	data, err := ioutil.ReadFile("./test.html")
+	assert.NoError(t, err)
+	changed := bytes.ReplaceAll(data, []byte(`class="totalcount"`), []byte(`class="count"`))
+
+	var hookURL string
+	var hookErr *LayoutError
+	client := NewClient("newyork", WithLayoutHook(func(url string, err *LayoutError) {
+		hookURL = url
+		hookErr = err
+	})).(*Client)
+	client.Request = &mockFetcher{data: changed}
+
+	url := "https://sfbay.craigslist.org/search/atq?query=antique&sort=rel"
+	_, err = client.GetListings(context.Background(), url)
+	assert.True(t, errors.Is(err, ErrLayoutChanged))
+
+	assert.Equal(t, url, hookURL)
+	assert.Equal(t, []string{AnchorTotalCount}, hookErr.Missing)
+}
+
+func TestWithSelectors(t *testing.T) {
+	called := false
+	client := NewClient("newyork",
+		WithSelectors(Selectors{Hood: "span[data-hood"}),
+		WithLayoutHook(func(url string, err *LayoutError) { called = true }),
+	).(*Client)
+	m := &mockFetcher{}
+	client.Request = m
+
+	_, err := client.GetListings(context.Background(), "https://sfbay.craigslist.org/search/atq?query=antique&sort=rel")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrLayoutChanged))
+	assert.False(t, called)
+	assert.NotEqual(t, "https://sfbay.craigslist.org/search/atq?query=antique&sort=rel", m.lastURL)
+}
+
+func TestSubAreas(t *testing.T) {
+	client := Client{Location: "newyork", Request: &mockFetcher{}}
+
+	result, err := client.GetListings(context.Background(), "https://newyork.craigslist.org/search/atq?query=antique&sort=rel")
+	assert.NoError(t, err)
+
+	first := result.Listings[0]
+	assert.Equal(t, "Throgs Neck", first.Hood)
+	assert.Equal(t, "brx", first.SubAreaAbbr)
+	assert.Equal(t, &SubArea{Abbreviation: "brx", Description: "bronx", ShortDescription: "bronx", SubAreaID: 4}, first.SubArea)
+
+	second := result.Listings[1]
+	assert.Equal(t, "QUEENS", second.Hood)
+	assert.Equal(t, "queens", second.SubArea.Description)
+}
+
+func TestSubAreaSearch(t *testing.T) {
+	t.Run("should validate subareas once areas are loaded", func(t *testing.T) {
+		client := Client{Location: "newyork", Request: &mockFetcher{}}
+
+		// areas are unknown until GetTimezones runs
+		_, err := client.BuildURL("xbox", Options{SubArea: "brk"})
+		assert.EqualError(t, err, `unable to validate subarea "brk": areas are not loaded, call GetTimezones first`)
+
+		_, err = client.GetTimezones(context.Background())
+		assert.NoError(t, err)
+
+		url, err := client.BuildURL("xbox", Options{SubArea: "brk"})
+		assert.NoError(t, err)
+		assert.Equal(t, "https://newyork.craigslist.org/search/brk/sss?query=xbox&sort=rel", url)
+
+		_, err = client.BuildURL("xbox", Options{SubArea: "xyz"})
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+		assert.EqualError(t, err, `invalid options: unknown subarea "xyz" of newyork`)
+	})
+
+	t.Run("GetListings should load the areas for a preset TimezoneMap", func(t *testing.T) {
+		timezones := map[string]string{"newyork": "America/New_York"}
+		client := Client{Location: "newyork", Request: &mockFetcher{}, TimezoneMap: timezones}
+
+		_, err := client.GetListings(context.Background(), "https://newyork.craigslist.org/search/brk/sss?query=xbox")
+		assert.NoError(t, err)
+		assert.Contains(t, client.AreaMap, "newyork")
+		assert.Equal(t, timezones, client.TimezoneMap)
+	})
+
+	t.Run("GetListings should reject unknown subareas", func(t *testing.T) {
+		client := Client{Location: "newyork", Request: &mockFetcher{}}
+
+		_, err := client.GetListings(context.Background(), "https://newyork.craigslist.org/search/xyz/sss?query=xbox")
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+	})
+
+	t.Run("GetListings should tag listings with the searched subarea", func(t *testing.T) {
+		p := &fixedParser{listings: []Listing{
+			{DataPID: "1", Title: "no link"},
+			{DataPID: "2", Area: "newyork", SubAreaAbbr: "que"},
+		}}
+		client := Client{Location: "newyork", Request: &mockFetcher{}, Parser: p}
+
+		result, err := client.GetListings(context.Background(), "https://newyork.craigslist.org/search/brk/sss?query=xbox")
+		assert.NoError(t, err)
+
+		tagged := result.Listings[0]
+		assert.Equal(t, "newyork", tagged.Area)
+		assert.Equal(t, "brk", tagged.SubAreaAbbr)
+		assert.Equal(t, &SubArea{Abbreviation: "brk", Description: "brooklyn", ShortDescription: "brooklyn", SubAreaID: 2}, tagged.SubArea)
+
+		// the link of a listing is more precise than the search
+		assert.Equal(t, "que", result.Listings[1].SubAreaAbbr)
+		assert.Equal(t, "queens", result.Listings[1].SubArea.Description)
+	})
+}
+
+func TestNearbyAreas(t *testing.T) {
+	t.Run("should list the nearby areas of the search", func(t *testing.T) {
+		client := Client{Location: "newyork", Request: &mockFetcher{}}
+
+		result, err := client.GetListings(context.Background(), "https://newyork.craigslist.org/search/atq?query=antique&sort=rel")
+		assert.NoError(t, err)
+		assert.Len(t, result.NearbyAreas, 62)
+		assert.Equal(t, NearbyArea{AreaID: 59, Name: "albany, NY", Abbreviation: "alb"}, result.NearbyAreas[0])
+	})
+
+	t.Run("should tag listings from nearby areas", func(t *testing.T) {
+		p := &fixedParser{listings: []Listing{
+			{DataPID: "1", Area: "newyork", SubAreaAbbr: "brk"},
+			{DataPID: "2", Area: "newjersey"},
+			{DataPID: "3"},
+		}}
+		client := Client{Location: "newyork", Request: &mockFetcher{}, Parser: p}
+
+		result, err := client.GetListings(context.Background(), "https://newyork.craigslist.org/search/sss?query=couch&searchNearby=1")
+		assert.NoError(t, err)
+		assert.False(t, result.Listings[0].Nearby)
+		assert.Equal(t, "brooklyn", result.Listings[0].SubArea.Description)
+		assert.True(t, result.Listings[1].Nearby)
+		assert.Equal(t, "newjersey", result.Listings[1].Area)
+		assert.False(t, result.Listings[2].Nearby)
+	})
+}
+
+func TestMapListings(t *testing.T) {
+	pins, err := ioutil.ReadFile("./test_map.json")
+	assert.NoError(t, err)
+	cluster, err := ioutil.ReadFile("./test_map_cluster.json")
+	assert.NoError(t, err)
+
+	newClient := func() (*Client, *mockFetcher) {
+		m := &mockFetcher{routes: map[string][]byte{
+			"/jsonsearch/atq?geocluster=": cluster,
+			"/jsonsearch/":                pins,
+		}}
+		return &Client{Location: "newyork", Request: m}, m
+	}
+
+	t.Run("should return every pin with coordinates", func(t *testing.T) {
+		client, m := newClient()
+
+		url := client.FormatURL("antique", Options{Category: "atq"})
+		result, err := client.GetMapListings(context.Background(), url, nil)
+		assert.NoError(t, err)
+
+		// the main response and the one cluster
+		assert.Equal(t, "https://newyork.craigslist.org/jsonsearch/atq?geocluster=1591652000&key=mTfHPWwmOAEy&query=antique&sort=rel", m.lastURL)
+		assert.True(t, result.Done)
+		assert.Equal(t, 4, result.TotalCount)
+		assert.Len(t, result.Listings, 4)
+
+		first := result.Listings[0]
+		assert.Equal(t, "7132606866", first.DataPID)
+		assert.Equal(t, "2020-06-08 17:45:12", first.Date)
+		assert.Equal(t, "$250", first.Price)
+		assert.Equal(t, 40.8262, first.Latitude)
+		assert.Equal(t, -73.8196, first.Longitude)
+		assert.Equal(t, 22, first.LocationAccuracy)
+		assert.Equal(t, []string{"00a0a_ggtoxMsCVLk_0CI0t2"}, first.ImageIDs)
+		assert.Equal(t, "bronx", first.SubArea.Description)
+
+		// clustered postings follow the pins of the main response
+		assert.Equal(t, "7138122864", result.Listings[2].DataPID)
+		assert.Equal(t, "", result.Listings[3].Price)
+	})
+
+	t.Run("should restrict listings to the bounding box", func(t *testing.T) {
+		client, _ := newClient()
+
+		// new york city, excluding the listing in stamford
+		bounds := &Bounds{North: 40.92, South: 40.49, East: -73.70, West: -74.26}
+
+		url := client.FormatURL("antique", Options{Category: "atq"})
+		result, err := client.GetMapListings(context.Background(), url, bounds)
+		assert.NoError(t, err)
+
+		assert.Len(t, result.Listings, 3)
+		for _, l := range result.Listings {
+			assert.NotEqual(t, "7127000001", l.DataPID)
+		}
+	})
+
+	t.Run("should send the bounding box", func(t *testing.T) {
+		client, m := newClient()
+		m.routes = map[string][]byte{"/jsonsearch/": []byte(`[[], {}]`)}
+
+		bounds := &Bounds{North: 40.92, South: 40.49, East: -73.7, West: -74.26}
+
+		url := client.FormatURL("antique", Options{Category: "atq"})
+		_, err := client.GetMapListings(context.Background(), url, bounds)
+		assert.NoError(t, err)
+
+		assert.Contains(t, m.lastURL, "max_lat=40.92")
+		assert.Contains(t, m.lastURL, "min_lat=40.49")
+		assert.Contains(t, m.lastURL, "max_lon=-73.7")
+		assert.Contains(t, m.lastURL, "min_lon=-74.26")
+	})
+
+	t.Run("should follow clusters within clusters once", func(t *testing.T) {
+		nested, err := ioutil.ReadFile("./test_map_nested.json")
+		assert.NoError(t, err)
+
+		client, m := newClient()
+		m.routes = map[string][]byte{
+			"/jsonsearch/":                          pins,
+			"/jsonsearch/atq?geocluster=1591652000": nested,
+			"/jsonsearch/atq?geocluster=1591652001": cluster,
+		}
+
+		url := client.FormatURL("antique", Options{Category: "atq"})
+		result, err := client.GetMapListings(context.Background(), url, nil)
+		assert.NoError(t, err)
+
+		// the main response, the nested cluster once and its inner cluster
+		assert.Len(t, result.Listings, 5)
+		assert.Equal(t, "7130000002", result.Listings[2].DataPID)
+		assert.Equal(t, "7138122864", result.Listings[3].DataPID)
+	})
+}
+
+func TestInternationalAreas(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		file     string
+		url      string
+		expected []Listing
+	}{
+		{
+			name: "french page in iso-8859-1",
+			file: "./test_fr.html",
+			url:  "https://paris.craigslist.org/search/sss?query=vendre&sort=rel",
+			expected: []Listing{
+				{Title: "Commode ancienne en chêne & marbre", Price: "€350", Hood: "Paris 11e"},
+				{Title: "Vélo de ville « Peugeot » très bon état", Price: "€120", Hood: "Ménilmontant"},
+			},
+		},
+		{
+			name: "japanese page with inline markup",
+			file: "./test_ja.html",
+			url:  "https://tokyo.craigslist.org/search/sss?query=sofa&sort=rel",
+			expected: []Listing{
+				{Title: "ソファ 美品 & 送料無料", Price: "¥15000", Hood: "渋谷区"},
+				{Title: "学習机 木製", Price: "¥8000", Hood: "世田谷"},
+			},
+		},
+		{
+			name: "japanese page in shift_jis",
+			file: "./test_sjis.html",
+			url:  "https://tokyo.craigslist.org/search/sss?query=sofa&sort=rel",
+			expected: []Listing{
+				{Title: "ソファ 美品 & 送料無料", Price: "¥15000", Hood: "渋谷区"},
+				{Title: "学習机 木製", Price: "¥8000", Hood: "世田谷"},
+			},
+		},
+		{
+			name: "spanish page in windows-1252",
+			file: "./test_es.html",
+			url:  "https://madrid.craigslist.org/search/sss?query=mesa&sort=rel",
+			expected: []Listing{
+				{Title: "Mesa de comedor — “extensible” año 1970", Price: "€200", Hood: "Chamberí"},
+				{Title: "Guitarra española ¡como nueva!", Price: "€90", Hood: "Lavapiés"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := ioutil.ReadFile(test.file)
+			assert.NoError(t, err)
+
+			// the charset is only declared by the page itself
+			client := Client{Location: "newyork", Request: &mockFetcher{data: data, contentType: "text/html"}}
+			result, err := client.GetListings(context.Background(), test.url)
+			assert.NoError(t, err)
+			assert.Len(t, result.Listings, len(test.expected))
+
+			for i, expected := range test.expected {
+				assert.Equal(t, expected.Title, result.Listings[i].Title)
+				assert.Equal(t, expected.Price, result.Listings[i].Price)
+				assert.Equal(t, expected.Hood, result.Listings[i].Hood)
+			}
+		})
+	}
+}
+
+func TestHostname(t *testing.T) {
+	t.Run("should parse the hostname of any search url", func(t *testing.T) {
+		for _, test := range []struct {
+			given    string
+			base     string
+			expected string
+		}{
+			{given: "https://newyork.craigslist.org/search/sss?query=xbox&sort=rel", base: base, expected: "newyork"},
+			{given: "http://newyork.craigslist.org/search/sss?query=xbox", base: base, expected: "newyork"},
+			{given: "https://sfbay.craigslist.org/search/eby/ata?query=lamp", base: base, expected: "sfbay"},
+			{given: "https://SFBay.Craigslist.org/d/antiques/search/ata", base: base, expected: "sfbay"},
+			{given: "http://newyork.localhost:8080/search/sss?query=xbox", base: "localhost:8080", expected: "newyork"},
+		} {
+			hostname, err := hostnameFromURL(test.given, test.base)
+			assert.NoError(t, err, test.given)
+			assert.Equal(t, test.expected, hostname, test.given)
+		}
+	})
+
+	t.Run("should reject urls that are not craigslist", func(t *testing.T) {
+		for _, given := range []string{
+			"https://newyork.example.com/search/sss",
+			"https://craigslist.org/search/sss",
+			"https://www.newyork.craigslist.org/search/sss",
+			"ftp://newyork.craigslist.org/search/sss",
+			"newyork.craigslist.org/search/sss",
+		} {
+			_, err := hostnameFromURL(given, base)
+			assert.True(t, errors.Is(err, ErrNotCraigslistURL), given)
+		}
+	})
+
+	t.Run("GetListings should return an error for non craigslist urls", func(t *testing.T) {
+		client := Client{Location: "newyork", Request: &mockFetcher{}}
+
+		_, err := client.GetListings(context.Background(), "https://newyork.example.com/search/sss")
+		assert.True(t, errors.Is(err, ErrNotCraigslistURL))
+	})
+
+	t.Run("should use the configured base domain", func(t *testing.T) {
+		client := NewClient("newyork", WithBaseDomain("localhost:8080")).(*Client)
+		client.Request = &mockFetcher{}
+
+		url := client.FormatURL("xbox", Options{})
+		assert.Equal(t, "https://newyork.localhost:8080/search/sss?query=xbox&sort=rel", url)
+
+		result, err := client.GetListings(context.Background(), url)
+		assert.NoError(t, err)
+		assert.Len(t, result.Listings, 120)
+	})
+}
+
 func TestTimezones(t *testing.T) {
 	t.Run("should populate client Timezone", func(t *testing.T) {
 		client := Client{Location: "newyork", Request: &mockFetcher{}}
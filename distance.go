@@ -0,0 +1,68 @@
+package gocraigslist
+
+import (
+	"math"
+	"sort"
+)
+
+// earthRadiusMiles is the mean radius of the earth, craigslist measures
+// search distance in miles.
+const earthRadiusMiles = 3958.8
+
+// Distance is the great circle distance in miles between two coordinates in
+// degrees.
+func Distance(lat1 float64, lon1 float64, lat2 float64, lon2 float64) float64 {
+	toRadians := func(degrees float64) float64 {
+		return degrees * math.Pi / 180
+	}
+
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusMiles * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// HasCoordinates reports whether the listing was returned with a location,
+// only the json backend and map search provide one.
+func (l Listing) HasCoordinates() bool {
+	return l.Latitude != 0 || l.Longitude != 0
+}
+
+// DistanceFrom is the distance in miles from the coordinate to the listing,
+// false when the listing has no coordinates.
+func (l Listing) DistanceFrom(latitude float64, longitude float64) (float64, bool) {
+	if !l.HasCoordinates() {
+		return 0, false
+	}
+
+	return Distance(latitude, longitude, l.Latitude, l.Longitude), true
+}
+
+// FilterByDistance keeps the listings within miles of the coordinate.
+// Listings without coordinates are dropped.
+func FilterByDistance(listings []Listing, latitude float64, longitude float64, miles float64) []Listing {
+	within := []Listing{}
+	for _, l := range listings {
+		if d, has := l.DistanceFrom(latitude, longitude); has && d <= miles {
+			within = append(within, l)
+		}
+	}
+
+	return within
+}
+
+// SortByDistance orders listings closest to the coordinate first, listings
+// without coordinates are moved to the end in their original order.
+func SortByDistance(listings []Listing, latitude float64, longitude float64) {
+	sort.SliceStable(listings, func(i, j int) bool {
+		a, hasA := listings[i].DistanceFrom(latitude, longitude)
+		b, hasB := listings[j].DistanceFrom(latitude, longitude)
+		if hasA != hasB {
+			return hasA
+		}
+		return a < b
+	})
+}
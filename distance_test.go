@@ -0,0 +1,57 @@
+package gocraigslist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	t.Run("should compute the great circle distance in miles", func(t *testing.T) {
+		for _, test := range []struct {
+			lat1, lon1, lat2, lon2 float64
+			expected               float64
+		}{
+			{lat1: 40.7506, lon1: -73.9971, lat2: 40.7506, lon2: -73.9971, expected: 0},
+			// penn station to union station, dc
+			{lat1: 40.7506, lon1: -73.9971, lat2: 38.8973, lon2: -77.0063, expected: 204.6},
+			// new york to san francisco
+			{lat1: 40.7142, lon1: -74.0064, lat2: 37.7749, lon2: -122.4194, expected: 2565.8},
+		} {
+			assert.InDelta(t, test.expected, Distance(test.lat1, test.lon1, test.lat2, test.lon2), 1)
+		}
+	})
+
+	listings := []Listing{
+		{DataPID: "far", Latitude: 40.0583, Longitude: -74.4057},
+		{DataPID: "unknown"},
+		{DataPID: "near", Latitude: 40.7484, Longitude: -73.9857},
+		{DataPID: "mid", Latitude: 40.6782, Longitude: -73.9442},
+	}
+
+	t.Run("should filter listings by distance", func(t *testing.T) {
+		within := FilterByDistance(listings, 40.7506, -73.9971, 10)
+
+		pids := []string{}
+		for _, l := range within {
+			pids = append(pids, l.DataPID)
+		}
+		assert.Equal(t, []string{"near", "mid"}, pids)
+	})
+
+	t.Run("should sort listings by distance", func(t *testing.T) {
+		sorted := append([]Listing{}, listings...)
+		SortByDistance(sorted, 40.7506, -73.9971)
+
+		pids := []string{}
+		for _, l := range sorted {
+			pids = append(pids, l.DataPID)
+		}
+		assert.Equal(t, []string{"near", "mid", "far", "unknown"}, pids)
+	})
+
+	t.Run("should report listings without coordinates", func(t *testing.T) {
+		_, has := Listing{}.DistanceFrom(40.7506, -73.9971)
+		assert.False(t, has)
+	})
+}
@@ -0,0 +1,221 @@
+package gocraigslist
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Condition is an item condition filter. Its value is the name craigslist
+// shows, the url uses the code returned by Code.
+type Condition string
+
+// Conditions craigslist can filter by.
+const (
+	ConditionNew       Condition = "new"
+	ConditionLikeNew   Condition = "like new"
+	ConditionExcellent Condition = "excellent"
+	ConditionGood      Condition = "good"
+	ConditionFair      Condition = "fair"
+	ConditionSalvage   Condition = "salvage"
+)
+
+// Language is a posting language filter, named by its ISO 639-1 code.
+type Language string
+
+// Languages craigslist can filter by.
+const (
+	LanguageAF Language = "af"
+	LanguageCA Language = "ca"
+	LanguageDA Language = "da"
+	LanguageDE Language = "de"
+	LanguageEN Language = "en"
+	LanguageES Language = "es"
+	LanguageFI Language = "fi"
+	LanguageFR Language = "fr"
+	LanguageIT Language = "it"
+	LanguageNL Language = "nl"
+	LanguageNO Language = "no"
+	LanguagePT Language = "pt"
+	LanguageSV Language = "sv"
+	LanguageTL Language = "tl"
+	LanguageTR Language = "tr"
+	LanguageZH Language = "zh"
+	LanguageAR Language = "ar"
+	LanguageJA Language = "ja"
+	LanguageKO Language = "ko"
+	LanguageRU Language = "ru"
+	LanguageVI Language = "vi"
+)
+
+// PostedBy restricts a search to listings from owners or dealers.
+type PostedBy string
+
+// Sellers craigslist can filter by, the empty value is the same as PostedByAll.
+const (
+	PostedByAll    PostedBy = "all"
+	PostedByOwner  PostedBy = "owner"
+	PostedByDealer PostedBy = "dealer"
+)
+
+// SortOrder is the order craigslist returns search results in.
+type SortOrder string
+
+// Orders craigslist can sort by, the empty value is the same as SortRelevance.
+const (
+	SortRelevance SortOrder = "relevance"
+	SortDate      SortOrder = "date"
+	SortPriceAsc  SortOrder = "priceasc"
+	SortPriceDesc SortOrder = "pricedsc"
+)
+
+var (
+	postedByMap  = map[string]string{"all": defCategory, "owner": defCategoryOwner, "dealer": defCategoryDealer}
+	sortOrderMap = map[string]string{"relevance": "rel", "date": "date", "priceasc": "priceasc", "pricedsc": "pricedsc"}
+)
+
+var (
+	conditionEnum = enum{kind: "condition", codes: conditionMap}
+	languageEnum  = enum{kind: "language", codes: languageMap}
+	postedByEnum  = enum{kind: "posted by", codes: postedByMap}
+	sortOrderEnum = enum{kind: "sort order", codes: sortOrderMap}
+)
+
+// String implements fmt.Stringer.
+func (c Condition) String() string { return string(c) }
+
+// Code is the value of the condition in a search url, empty when unknown.
+func (c Condition) Code() string { return conditionMap[string(c)] }
+
+// UnmarshalJSON accepts either the name or the code of a condition.
+func (c *Condition) UnmarshalJSON(data []byte) error {
+	return conditionEnum.unmarshal(data, (*string)(c))
+}
+
+// ParseCondition looks up a condition by name or code, ignoring case.
+func ParseCondition(s string) (Condition, error) {
+	name, err := conditionEnum.parse(s)
+	return Condition(name), err
+}
+
+// String implements fmt.Stringer.
+func (l Language) String() string { return string(l) }
+
+// Code is the value of the language in a search url, empty when unknown.
+func (l Language) Code() string { return languageMap[string(l)] }
+
+// UnmarshalJSON accepts either the name or the code of a language.
+func (l *Language) UnmarshalJSON(data []byte) error {
+	return languageEnum.unmarshal(data, (*string)(l))
+}
+
+// ParseLanguage looks up a language by name or code, ignoring case.
+func ParseLanguage(s string) (Language, error) {
+	name, err := languageEnum.parse(s)
+	return Language(name), err
+}
+
+// String implements fmt.Stringer.
+func (p PostedBy) String() string { return string(p) }
+
+// Code is the category searching everything for sale by the seller, empty
+// when unknown.
+func (p PostedBy) Code() string {
+	if p == "" {
+		return defCategory
+	}
+	return postedByMap[string(p)]
+}
+
+// UnmarshalJSON accepts either the name or the category code of a seller.
+func (p *PostedBy) UnmarshalJSON(data []byte) error {
+	return postedByEnum.unmarshal(data, (*string)(p))
+}
+
+// ParsePostedBy looks up a seller by name or category code, ignoring case.
+func ParsePostedBy(s string) (PostedBy, error) {
+	name, err := postedByEnum.parse(s)
+	return PostedBy(name), err
+}
+
+// String implements fmt.Stringer.
+func (s SortOrder) String() string { return string(s) }
+
+// Code is the value of the order in a search url, empty when unknown.
+func (s SortOrder) Code() string {
+	if s == "" {
+		return sortOrderMap[string(SortRelevance)]
+	}
+	return sortOrderMap[string(s)]
+}
+
+// UnmarshalJSON accepts either the name or the code of an order.
+func (s *SortOrder) UnmarshalJSON(data []byte) error {
+	return sortOrderEnum.unmarshal(data, (*string)(s))
+}
+
+// ParseSortOrder looks up an order by name or code, ignoring case.
+func ParseSortOrder(s string) (SortOrder, error) {
+	name, err := sortOrderEnum.parse(s)
+	return SortOrder(name), err
+}
+
+// enum reads the values of a string option type by name or craigslist code
+// for its Parse function and UnmarshalJSON method. Options encode to json
+// by name without a method of their own.
+type enum struct {
+	kind  string            // used in errors, e.g. "condition"
+	codes map[string]string // craigslist code by name
+}
+
+// parse finds the name of an option given either its name or its code,
+// ignoring case.
+func (e enum) parse(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for name := range e.codes {
+		if strings.ToLower(name) == s {
+			return name, nil
+		}
+	}
+
+	if name, has := lookupName(e.codes, s); has {
+		return name, nil
+	}
+
+	return "", fmt.Errorf("%w: unknown %s %q", ErrInvalidOptions, e.kind, s)
+}
+
+// unmarshal decodes a json name or code into name, see unmarshalEnum.
+func (e enum) unmarshal(data []byte, name *string) error {
+	return unmarshalEnum(data, func(s string) error {
+		parsed, err := e.parse(s)
+		*name = parsed
+		return err
+	})
+}
+
+// unmarshalEnum passes a json string, or a number for numeric codes, to parse.
+// null and the empty string leave the value untouched.
+func unmarshalEnum(data []byte, parse func(string) error) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] != '"' {
+		var n json.Number
+		err := json.Unmarshal(data, &n)
+		if err != nil {
+			return err
+		}
+		return parse(n.String())
+	}
+
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil || s == "" {
+		return err
+	}
+	return parse(s)
+}
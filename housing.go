@@ -0,0 +1,36 @@
+package gocraigslist
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+)
+
+const squareMetersPerSquareFoot = 0.09290304
+
+var (
+	housingBedrooms = regexp.MustCompile(`(\d+)\s*br\b`)
+	housingArea     = regexp.MustCompile(`(\d+)\s*(ft|m)\s*2`)
+)
+
+// parseHousing reads the bedrooms and area out of the housing details of a
+// result row ("2br - 900ft2 -"). The area is returned in both ft² and m²,
+// whichever unit the row used, and every missing value is 0.
+func parseHousing(text string) (bedrooms int, squareFeet int, squareMeters int) {
+	if match := housingBedrooms.FindStringSubmatch(text); match != nil {
+		bedrooms, _ = strconv.Atoi(match[1])
+	}
+
+	if match := housingArea.FindStringSubmatch(text); match != nil {
+		area, _ := strconv.Atoi(match[1])
+		if match[2] == "ft" {
+			squareFeet = area
+			squareMeters = int(math.Round(float64(area) * squareMetersPerSquareFoot))
+		} else {
+			squareMeters = area
+			squareFeet = int(math.Round(float64(area) / squareMetersPerSquareFoot))
+		}
+	}
+
+	return bedrooms, squareFeet, squareMeters
+}
@@ -0,0 +1,266 @@
+package gocraigslist
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// HousingOptions are the filters of the housing categories (apa, roo, sub
+// and the rest of hhh). Ranges are inclusive, either end may be left empty.
+// They are whole numbers except bathrooms, which may be decimals like 1.5.
+type HousingOptions struct {
+	MinBedrooms  string       // example = 1
+	MaxBedrooms  string       // example = 3
+	MinBathrooms string       // example = 1.5
+	MaxBathrooms string       // example = 2
+	MinSqft      string       // example = 500
+	MaxSqft      string       // example = 1200
+	CatsOK       bool         // dev note - uses 1 for true
+	DogsOK       bool         // dev note - uses 1 for true
+	Furnished    bool         // dev note - uses 1 for true
+	NoSmoking    bool         // dev note - uses 1 for true
+	Wheelchair   bool         // wheelchair accessible; dev note - uses 1 for true
+	Laundry      []Laundry    // [w/d in unit, 1], [laundry in bldg, 2], [laundry on site, 3], [w/d hookups, 4], [no laundry on site, 5]
+	Parking      []Parking    // [carport, 1], [attached garage, 2], [detached garage, 3], [off-street parking, 4], [street parking, 5], [valet parking, 6], [no parking, 7]
+	Availability Availability // [within 30 days, 1], [beyond 30 days, 2]; defaults to any date
+}
+
+// Laundry is where a unit's laundry is.
+type Laundry string
+
+// Laundry options craigslist can filter by.
+const (
+	LaundryInUnit     Laundry = "w/d in unit"
+	LaundryInBuilding Laundry = "laundry in bldg"
+	LaundryOnSite     Laundry = "laundry on site"
+	LaundryHookups    Laundry = "w/d hookups"
+	LaundryNone       Laundry = "no laundry on site"
+)
+
+// Parking is the parking that comes with a unit.
+type Parking string
+
+// Parking options craigslist can filter by.
+const (
+	ParkingCarport        Parking = "carport"
+	ParkingAttachedGarage Parking = "attached garage"
+	ParkingDetachedGarage Parking = "detached garage"
+	ParkingOffStreet      Parking = "off-street parking"
+	ParkingStreet         Parking = "street parking"
+	ParkingValet          Parking = "valet parking"
+	ParkingNone           Parking = "no parking"
+)
+
+// Availability is when a unit can be moved into, the empty value is any date.
+type Availability string
+
+// Availabilities craigslist can filter by.
+const (
+	AvailableWithin30Days Availability = "within 30 days"
+	AvailableBeyond30Days Availability = "beyond 30 days"
+)
+
+// craigslist codes of the HousingOptions filters
+var (
+	laundryMap      = map[string]string{"w/d in unit": "1", "laundry in bldg": "2", "laundry on site": "3", "w/d hookups": "4", "no laundry on site": "5"}
+	parkingMap      = map[string]string{"carport": "1", "attached garage": "2", "detached garage": "3", "off-street parking": "4", "street parking": "5", "valet parking": "6", "no parking": "7"}
+	availabilityMap = map[string]string{"within 30 days": "1", "beyond 30 days": "2"}
+)
+
+var (
+	laundryEnum      = enum{kind: "laundry", codes: laundryMap}
+	parkingEnum      = enum{kind: "parking", codes: parkingMap}
+	availabilityEnum = enum{kind: "availability", codes: availabilityMap}
+)
+
+// Code is the value of the laundry option in a search url, empty when unknown.
+func (l Laundry) Code() string { return laundryMap[string(l)] }
+
+// String implements fmt.Stringer.
+func (l Laundry) String() string { return string(l) }
+
+// UnmarshalJSON accepts either the name or the code of a laundry option.
+func (l *Laundry) UnmarshalJSON(data []byte) error {
+	return laundryEnum.unmarshal(data, (*string)(l))
+}
+
+// ParseLaundry looks up a laundry option by name or code, ignoring case.
+func ParseLaundry(s string) (Laundry, error) {
+	name, err := laundryEnum.parse(s)
+	return Laundry(name), err
+}
+
+// Code is the value of the parking option in a search url, empty when unknown.
+func (p Parking) Code() string { return parkingMap[string(p)] }
+
+// String implements fmt.Stringer.
+func (p Parking) String() string { return string(p) }
+
+// UnmarshalJSON accepts either the name or the code of a parking option.
+func (p *Parking) UnmarshalJSON(data []byte) error {
+	return parkingEnum.unmarshal(data, (*string)(p))
+}
+
+// ParseParking looks up a parking option by name or code, ignoring case.
+func ParseParking(s string) (Parking, error) {
+	name, err := parkingEnum.parse(s)
+	return Parking(name), err
+}
+
+// Code is the value of the availability in a search url, empty when unknown.
+func (a Availability) Code() string { return availabilityMap[string(a)] }
+
+// String implements fmt.Stringer.
+func (a Availability) String() string { return string(a) }
+
+// UnmarshalJSON accepts either the name or the code of an availability.
+func (a *Availability) UnmarshalJSON(data []byte) error {
+	return availabilityEnum.unmarshal(data, (*string)(a))
+}
+
+// ParseAvailability looks up an availability by name or code, ignoring case.
+func ParseAvailability(s string) (Availability, error) {
+	name, err := availabilityEnum.parse(s)
+	return Availability(name), err
+}
+
+// ranges pairs the range filters with their url parameters.
+func (h HousingOptions) ranges() []struct{ param, value string } {
+	return []struct{ param, value string }{
+		{"min_bedrooms", h.MinBedrooms},
+		{"max_bedrooms", h.MaxBedrooms},
+		{"min_bathrooms", h.MinBathrooms},
+		{"max_bathrooms", h.MaxBathrooms},
+		{"minSqft", h.MinSqft},
+		{"maxSqft", h.MaxSqft},
+	}
+}
+
+// flags pairs the boolean filters with their url parameters.
+func (h HousingOptions) flags() []struct {
+	param string
+	value bool
+} {
+	return []struct {
+		param string
+		value bool
+	}{
+		{"pets_cat", h.CatsOK},
+		{"pets_dog", h.DogsOK},
+		{"is_furnished", h.Furnished},
+		{"no_smoking", h.NoSmoking},
+		{"wheelchaccess", h.Wheelchair},
+	}
+}
+
+// args encodes the filters as query arguments in the format of FormatURL.
+func (h HousingOptions) args() string {
+	var args string
+	for _, r := range h.ranges() {
+		if r.value != "" {
+			args += "&" + r.param + "=" + r.value
+		}
+	}
+
+	for _, f := range h.flags() {
+		if f.value {
+			args += "&" + f.param + "=1"
+		}
+	}
+
+	for _, l := range h.Laundry {
+		args += "&laundry=" + l.Code()
+	}
+
+	for _, p := range h.Parking {
+		args += "&parking=" + p.Code()
+	}
+
+	if h.Availability != "" {
+		args += "&availabilityMode=" + h.Availability.Code()
+	}
+
+	return args
+}
+
+// validate reports the first filter FormatURL cannot encode.
+func (h HousingOptions) validate() error {
+	err := validateRange("bedrooms", h.MinBedrooms, h.MaxBedrooms)
+	if err != nil {
+		return err
+	}
+
+	// half bathrooms are common, 1.5
+	err = validateDecimalRange("bathrooms", h.MinBathrooms, h.MaxBathrooms)
+	if err != nil {
+		return err
+	}
+
+	err = validateRange("sqft", h.MinSqft, h.MaxSqft)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range h.Laundry {
+		if l.Code() == "" {
+			return fmt.Errorf("%w: unknown laundry %q", ErrInvalidOptions, l)
+		}
+	}
+
+	for _, p := range h.Parking {
+		if p.Code() == "" {
+			return fmt.Errorf("%w: unknown parking %q", ErrInvalidOptions, p)
+		}
+	}
+
+	if h.Availability != "" && h.Availability.Code() == "" {
+		return fmt.Errorf("%w: unknown availability %q", ErrInvalidOptions, h.Availability)
+	}
+
+	return nil
+}
+
+// parseHousingOptions reads the filters of HousingOptions from a search url
+// query.
+func parseHousingOptions(q url.Values) (HousingOptions, error) {
+	h := HousingOptions{
+		MinBedrooms:  q.Get("min_bedrooms"),
+		MaxBedrooms:  q.Get("max_bedrooms"),
+		MinBathrooms: q.Get("min_bathrooms"),
+		MaxBathrooms: q.Get("max_bathrooms"),
+		MinSqft:      q.Get("minSqft"),
+		MaxSqft:      q.Get("maxSqft"),
+		CatsOK:       q.Get("pets_cat") == "1",
+		DogsOK:       q.Get("pets_dog") == "1",
+		Furnished:    q.Get("is_furnished") == "1",
+		NoSmoking:    q.Get("no_smoking") == "1",
+		Wheelchair:   q.Get("wheelchaccess") == "1",
+	}
+
+	for _, code := range q["laundry"] {
+		name, has := lookupName(laundryMap, code)
+		if !has {
+			return h, fmt.Errorf("unknown laundry code: %s", code)
+		}
+		h.Laundry = append(h.Laundry, Laundry(name))
+	}
+
+	for _, code := range q["parking"] {
+		name, has := lookupName(parkingMap, code)
+		if !has {
+			return h, fmt.Errorf("unknown parking code: %s", code)
+		}
+		h.Parking = append(h.Parking, Parking(name))
+	}
+
+	// 0 is craigslist's code for any date
+	if code := q.Get("availabilityMode"); code != "" && code != "0" {
+		name, has := lookupName(availabilityMap, code)
+		if !has {
+			return h, fmt.Errorf("unknown availability code: %s", code)
+		}
+		h.Availability = Availability(name)
+	}
+
+	return h, nil
+}
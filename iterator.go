@@ -2,7 +2,6 @@ package gocraigslist
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -21,7 +20,8 @@ type Result struct {
 	Timezone    string
 	TotalCount  int
 	CurrentPage int
-	SearchURL   string // the original search url without pagination
+	SearchURL   string       // the original search url without pagination
+	NearbyAreas []NearbyArea // areas that can be included in the search, as listed on its first page
 	Err         error
 }
 
@@ -52,7 +52,7 @@ func newResult(c *Client, url string, totalCount int, listings []Listing, timezo
 	return &r
 }
 
-// Next page of listings. This will call the librarys fn GetListings.
+// Next page of listings. This uses the same backend as GetListings.
 // Note: Because postings could be happening as this is fetching results, there is
 // the possibility of some duplicates coming through.
 // 		Example in seconds:
@@ -65,25 +65,20 @@ func (r *Result) Next(ctx context.Context, date time.Time) (*Result, error) {
 	nextPageStart := r.CurrentPage * 120
 	nextPageURL := r.SearchURL + page + strconv.Itoa(nextPageStart)
 
-	resp, err := r.Client.Request.fetch(ctx, nextPageURL)
+	hostname, err := hostnameFromURL(r.SearchURL, r.Client.baseDomain())
 	if err != nil {
-		resp.Body.Close()
 		r.Done = true
 		r.Listings = []Listing{}
-		return r, fmt.Errorf("error fetching from url: %v", err)
-	}
-
-	var listings []Listing
-	if date == nilTime {
-		listings, _, err = parseSearchResults(resp.Body)
-	} else {
-		listings, _, err = parseSearchResultsAfter(resp.Body, date)
+		return r, err
 	}
 
+	results, err := r.Client.search(ctx, nextPageURL, hostname, r.Timezone, date)
 	if err != nil {
 		r.Done = true
+		r.Listings = []Listing{}
 		return r, err
 	}
+	listings := results.Listings
 
 	r.Listings = listings
 
@@ -0,0 +1,230 @@
+package gocraigslist
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// JobOptions are the filters of the jobs (jjj) and gigs (ggg) categories.
+type JobOptions struct {
+	EmploymentType []EmploymentType // [full-time, 1], [part-time, 2], [contract, 3], [employee's choice, 4]
+	Telecommuting  bool             // dev note - uses 1 for true
+	Internship     bool             // dev note - uses 1 for true
+	Nonprofit      bool             // dev note - uses 1 for true
+	Pay            GigPay           // gigs only: [paid, yes], [unpaid, no]; defaults to both
+}
+
+// EmploymentType is the kind of position a job posting is for.
+type EmploymentType string
+
+// Employment types craigslist can filter by.
+const (
+	EmploymentFullTime       EmploymentType = "full-time"
+	EmploymentPartTime       EmploymentType = "part-time"
+	EmploymentContract       EmploymentType = "contract"
+	EmploymentEmployeeChoice EmploymentType = "employee's choice"
+)
+
+// GigPay is whether a gig pays, the empty value is either.
+type GigPay string
+
+// Gig pay craigslist can filter by.
+const (
+	GigPaid   GigPay = "paid"
+	GigUnpaid GigPay = "unpaid"
+)
+
+// craigslist codes of the JobOptions filters
+var (
+	employmentTypeMap = map[string]string{"full-time": "1", "part-time": "2", "contract": "3", "employee's choice": "4"}
+	gigPayMap         = map[string]string{"paid": "yes", "unpaid": "no"}
+)
+
+var (
+	employmentTypeEnum = enum{kind: "employment type", codes: employmentTypeMap}
+	gigPayEnum         = enum{kind: "gig pay", codes: gigPayMap}
+)
+
+// Code is the value of the employment type in a search url, empty when unknown.
+func (e EmploymentType) Code() string { return employmentTypeMap[string(e)] }
+
+// String implements fmt.Stringer.
+func (e EmploymentType) String() string { return string(e) }
+
+// UnmarshalJSON accepts either the name or the code of an employment type.
+func (e *EmploymentType) UnmarshalJSON(data []byte) error {
+	return employmentTypeEnum.unmarshal(data, (*string)(e))
+}
+
+// ParseEmploymentType looks up an employment type by name or code, ignoring case.
+func ParseEmploymentType(s string) (EmploymentType, error) {
+	name, err := employmentTypeEnum.parse(s)
+	return EmploymentType(name), err
+}
+
+// Code is the value of the gig pay in a search url, empty when unknown.
+func (g GigPay) Code() string { return gigPayMap[string(g)] }
+
+// String implements fmt.Stringer.
+func (g GigPay) String() string { return string(g) }
+
+// UnmarshalJSON accepts either the name or the code of a gig pay.
+func (g *GigPay) UnmarshalJSON(data []byte) error {
+	return gigPayEnum.unmarshal(data, (*string)(g))
+}
+
+// ParseGigPay looks up a gig pay by name or code, ignoring case.
+func ParseGigPay(s string) (GigPay, error) {
+	name, err := gigPayEnum.parse(s)
+	return GigPay(name), err
+}
+
+// args encodes the filters as query arguments in the format of FormatURL.
+func (j JobOptions) args() string {
+	var args string
+	for _, e := range j.EmploymentType {
+		args += "&employment_type=" + e.Code()
+	}
+
+	if j.Telecommuting {
+		args += "&is_telecommuting=1"
+	}
+
+	if j.Internship {
+		args += "&is_internship=1"
+	}
+
+	if j.Nonprofit {
+		args += "&is_nonprofit=1"
+	}
+
+	if j.Pay != "" {
+		args += "&is_paid=" + j.Pay.Code()
+	}
+
+	return args
+}
+
+// validate reports the first filter FormatURL cannot encode.
+func (j JobOptions) validate() error {
+	for _, e := range j.EmploymentType {
+		if e.Code() == "" {
+			return fmt.Errorf("%w: unknown employment type %q", ErrInvalidOptions, e)
+		}
+	}
+
+	if j.Pay != "" && j.Pay.Code() == "" {
+		return fmt.Errorf("%w: unknown gig pay %q", ErrInvalidOptions, j.Pay)
+	}
+
+	return nil
+}
+
+// parseJobOptions reads the filters of JobOptions from a search url query.
+func parseJobOptions(q url.Values) (JobOptions, error) {
+	j := JobOptions{
+		Telecommuting: q.Get("is_telecommuting") == "1",
+		Internship:    q.Get("is_internship") == "1",
+		Nonprofit:     q.Get("is_nonprofit") == "1",
+	}
+
+	for _, code := range q["employment_type"] {
+		name, has := lookupName(employmentTypeMap, code)
+		if !has {
+			return j, fmt.Errorf("unknown employment type code: %s", code)
+		}
+		j.EmploymentType = append(j.EmploymentType, EmploymentType(name))
+	}
+
+	if code := q.Get("is_paid"); code != "" && code != "all" {
+		name, has := lookupName(gigPayMap, code)
+		if !has {
+			return j, fmt.Errorf("unknown gig pay code: %s", code)
+		}
+		j.Pay = GigPay(name)
+	}
+
+	return j, nil
+}
+
+// JobListing is a listing of the jobs or gigs categories along with the
+// details only found on its posting page.
+type JobListing struct {
+	Listing
+	Compensation   string         // as written by the poster, example = $25/hr
+	EmploymentType EmploymentType // example = full-time, kept as written when unknown
+}
+
+// jobAttrs matches the "label: value" attributes of a posting page, both the
+// older p.attrgroup spans and the newer .attr rows.
+const jobAttrs = "p.attrgroup > span, .attrgroup .attr"
+
+// GetJobListing fetches the posting page of a listing from a jobs or gigs
+// search to fill in its compensation and employment type. Either is left
+// empty when the posting does not state it.
+func (c *Client) GetJobListing(ctx context.Context, listing Listing) (*JobListing, error) {
+	if listing.Link == "" {
+		return nil, fmt.Errorf("listing %s has no link", listing.DataPID)
+	}
+
+	resp, err := c.Request.fetch(ctx, listing.Link)
+	if err != nil {
+		return nil, fmt.Errorf("error sending http request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the http body: %v", err)
+	}
+
+	data, err = toUTF8(data, detectCharset(resp.Header.Get("Content-Type"), data))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the http body: %v", err)
+	}
+
+	job, err := parseJobPosting(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	job.Listing = listing
+
+	return job, nil
+}
+
+// parseJobPosting reads the job attributes of a posting page.
+func parseJobPosting(data io.Reader) (*JobListing, error) {
+	doc, err := html.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse html: %v", err)
+	}
+
+	job := &JobListing{}
+	for _, attr := range querySelectorAll(doc, jobAttrs) {
+		pieces := strings.SplitN(findText(attr), ":", 2)
+		if len(pieces) != 2 {
+			continue
+		}
+
+		value := strings.TrimSpace(pieces[1])
+		switch strings.ToLower(strings.TrimSpace(pieces[0])) {
+		case "compensation":
+			job.Compensation = value
+		case "employment type":
+			employment, err := ParseEmploymentType(value)
+			if err != nil {
+				employment = EmploymentType(value)
+			}
+			job.EmploymentType = employment
+		}
+	}
+
+	return job, nil
+}
@@ -0,0 +1,222 @@
+package gocraigslist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Item fields in the json api are positional. The ones below are arrays
+// whose first element is a tag describing the rest of the array.
+const (
+	jsonTagImages = 4
+	jsonTagSlug   = 6
+	jsonTagPrice  = 10
+)
+
+// jsonSearchResponse is the body returned by the json search api. Posting ids
+// and dates on each item are offsets from the values in the decode section.
+type jsonSearchResponse struct {
+	Data struct {
+		Decode struct {
+			Locations     [][]interface{} `json:"locations"`
+			MinPostedDate int64           `json:"minPostedDate"`
+			MinPostingID  int64           `json:"minPostingId"`
+		} `json:"decode"`
+		Items            [][]interface{} `json:"items"`
+		TotalResultCount int             `json:"totalResultCount"`
+	} `json:"data"`
+}
+
+// jsonPageSize is the number of results requested per batch, the page size
+// Result.Next steps through.
+const jsonPageSize = 120
+
+// jsonSearchURL converts a search url built by FormatURL into the equivalent
+// request against the json search api. The api addresses areas by id, so the
+// hostname must be one of the areas loaded by GetTimezones. The api ignores
+// the s offset of a search page, it is moved into the batch instead.
+func jsonSearchURL(searchURL string, areas map[string]Area, hostname string) (string, error) {
+	area, has := areas[hostname]
+	if !has {
+		return "", fmt.Errorf("unknown area %q, the json api needs its area id", hostname)
+	}
+
+	u, err := url.Parse(searchURL)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	offset := 0
+	if s := q.Get("s"); s != "" {
+		offset, err = strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			return "", fmt.Errorf("invalid page offset: %s", s)
+		}
+	}
+	q.Del("s")
+
+	q.Set("batch", fmt.Sprintf("%d-%d-%d-0-0", area.AreaID, offset, jsonPageSize))
+	q.Set("cc", "US")
+	q.Set("lang", "en")
+	q.Set("searchPath", strings.TrimPrefix(u.Path, defPath))
+
+	return jsonAPIURL + "?" + q.Encode(), nil
+}
+
+// searchCategory returns the category code at the end of a search url's path.
+func searchCategory(searchURL string) string {
+	u, err := url.Parse(searchURL)
+	if err != nil {
+		return defCategory
+	}
+
+	pieces := strings.Split(strings.Trim(u.Path, "/"), "/")
+	return pieces[len(pieces)-1]
+}
+
+// JSONParser parses responses from craigslist's json search api.
+type JSONParser struct{}
+
+// Parse implements Parser.
+func (p *JSONParser) Parse(data io.Reader, options ParseOptions) (*Page, error) {
+	decoder := json.NewDecoder(data)
+	decoder.UseNumber()
+
+	body := jsonSearchResponse{}
+	err := decoder.Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode data: %v", err)
+	}
+
+	loc := options.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	decode := body.Data.Decode
+	listings := []Listing{}
+	for _, item := range body.Data.Items {
+		if len(item) < 5 {
+			continue
+		}
+
+		pidOffset, _ := jsonInt(item[0])
+		dateOffset, _ := jsonInt(item[1])
+
+		posted := time.Unix(decode.MinPostedDate+dateOffset, 0).In(loc)
+		if options.Cutoff != nilTime && posted.Before(options.Cutoff) {
+			break
+		}
+
+		newListing := Listing{
+			DataPID: strconv.FormatInt(decode.MinPostingID+pidOffset, 10),
+			Date:    posted.Format("2006-01-02 15:04:05"),
+		}
+
+		if price, ok := jsonInt(item[3]); ok && price >= 0 {
+			newListing.Price = "$" + strconv.FormatInt(price, 10)
+		}
+
+		var hostname, subarea string
+		if location, ok := item[4].(string); ok {
+			hostname, subarea = jsonLocation(location, decode.Locations)
+			pieces := strings.Split(location, "~")
+			if len(pieces) >= 3 {
+				newListing.Latitude, _ = strconv.ParseFloat(pieces[1], 64)
+				newListing.Longitude, _ = strconv.ParseFloat(pieces[2], 64)
+			}
+		}
+
+		var slug string
+		for _, field := range item[5:] {
+			switch v := field.(type) {
+			case string:
+				// the title is the only bare string after the location
+				newListing.Title = v
+			case []interface{}:
+				if len(v) < 2 {
+					continue
+				}
+				tag, _ := jsonInt(v[0])
+				switch tag {
+				case jsonTagImages:
+					ids := []string{}
+					for _, id := range v[1:] {
+						if s, ok := id.(string); ok {
+							ids = append(ids, s)
+						}
+					}
+					newListing.ImageIDs = parseImageIDs(ids)
+				case jsonTagSlug:
+					slug, _ = v[1].(string)
+				case jsonTagPrice:
+					if s, ok := v[1].(string); ok {
+						newListing.Price = s
+					}
+				}
+			}
+		}
+
+		if hostname != "" && slug != "" {
+			ref := ListingRef{
+				Area:       hostname,
+				SubArea:    subarea,
+				Category:   options.Category,
+				Slug:       slug,
+				PostID:     newListing.DataPID,
+				BaseDomain: options.BaseDomain,
+			}
+			newListing.Link = ref.URL()
+		}
+		finishListing(&newListing)
+		if newListing.Area == "" {
+			newListing.Area = hostname
+			newListing.SubAreaAbbr = subarea
+		}
+
+		listings = append(listings, newListing)
+	}
+
+	return &Page{Listings: listings, TotalCount: body.Data.TotalResultCount}, nil
+}
+
+// jsonLocation resolves the "location:description~lat~lon" field of an item to
+// a hostname and subarea abbreviation. The location index points into the decode
+// section's locations, each of which is [areaID, hostname, subarea].
+func jsonLocation(location string, locations [][]interface{}) (hostname string, subarea string) {
+	index := strings.Split(strings.Split(location, "~")[0], ":")[0]
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 || i >= len(locations) {
+		return "", ""
+	}
+
+	entry := locations[i]
+	if len(entry) > 1 {
+		hostname, _ = entry[1].(string)
+	}
+	if len(entry) > 2 {
+		subarea, _ = entry[2].(string)
+	}
+
+	return hostname, subarea
+}
+
+func jsonInt(v interface{}) (int64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+
+	i, err := n.Int64()
+	if err != nil {
+		return 0, false
+	}
+
+	return i, true
+}
@@ -0,0 +1,86 @@
+package gocraigslist
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Anchors of the legacy layout reported by LayoutError when missing.
+const (
+	AnchorResults    = "sortable-results"
+	AnchorRows       = "rows"
+	AnchorTotalCount = "totalcount"
+	AnchorResultDate = "result-date"
+)
+
+// ErrLayoutChanged is matched by every LayoutError, check for it with errors.Is.
+var ErrLayoutChanged = errors.New("craigslist layout changed")
+
+// LayoutError is returned by a Parser when the markup it depends on is
+// missing from a page, which usually means craigslist changed its layout.
+type LayoutError struct {
+	Missing []string // the anchors that could not be found
+}
+
+func (e *LayoutError) Error() string {
+	return fmt.Sprintf("%v: missing %s", ErrLayoutChanged, strings.Join(e.Missing, ", "))
+}
+
+// Is reports whether target is ErrLayoutChanged.
+func (e *LayoutError) Is(target error) bool {
+	return target == ErrLayoutChanged
+}
+
+// validateLegacyLayout checks every anchor the legacy parser reads before any
+// listing is extracted. Rows are only checked for a result-date when the
+// list of rows itself was found, and only a list where no row has a date
+// counts as missing it, single rows with a bad date are skipped later.
+func validateLegacyLayout(resultSection *html.Node, resultList *html.Node, totalCountSection *html.Node, sel compiledSelectors) error {
+	missing := []string{}
+
+	if resultSection == nil {
+		missing = append(missing, AnchorResults)
+	}
+
+	if resultList == nil {
+		missing = append(missing, AnchorRows)
+	}
+
+	if totalCountSection == nil {
+		missing = append(missing, AnchorTotalCount)
+	}
+
+	if resultList != nil {
+		rows, dated := 0, false
+		for current := resultList.FirstChild; current != nil; current = current.NextSibling {
+			if current.Type != html.ElementNode {
+				continue
+			}
+
+			info, _ := sel.Info.first(current)
+			if info == nil {
+				continue
+			}
+
+			rows++
+			datetimeNode, _ := sel.Date.first(info)
+			if _, ok := parseResultDate(datetimeNode); ok {
+				dated = true
+				break
+			}
+		}
+
+		if rows > 0 && !dated {
+			missing = append(missing, AnchorResultDate)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &LayoutError{Missing: missing}
+	}
+
+	return nil
+}
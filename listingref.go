@@ -0,0 +1,123 @@
+package gocraigslist
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ListingRef holds the pieces encoded in a posting link such as
+// https://newyork.craigslist.org/brx/atq/d/bronx-vintage-electric-tomato-strainer/7132606866.html
+type ListingRef struct {
+	Area       string // hostname of the area, newyork
+	SubArea    string // brx, empty for areas without subareas
+	Category   string // atq
+	Slug       string // bronx-vintage-electric-tomato-strainer, empty on older links
+	PostID     string // 7132606866
+	BaseDomain string // domain the area is a subdomain of, empty for craigslist.org
+}
+
+// ParseListingURL decomposes a posting link into a ListingRef. Both the
+// current /[subarea/]category/d/slug/id.html form and the older
+// /[subarea/]category/id.html form are accepted.
+func ParseListingURL(link string) (ListingRef, error) {
+	return parseListingURL(link, base)
+}
+
+// parseListingURL is ParseListingURL for links on baseDomain. Parsers use it
+// with an empty baseDomain, which accepts any host, as the pages they parse
+// may come from a client with a different base domain.
+func parseListingURL(link string, baseDomain string) (ListingRef, error) {
+	ref := ListingRef{}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return ref, fmt.Errorf("unable to parse listing url: %v", err)
+	}
+
+	if baseDomain == "" {
+		pieces := strings.SplitN(u.Host, ".", 2)
+		ref.Area = pieces[0]
+		if len(pieces) == 2 {
+			baseDomain = strings.ToLower(pieces[1])
+		}
+	} else {
+		ref.Area, err = hostnameFromURL(link, baseDomain)
+		if err != nil {
+			return ref, err
+		}
+	}
+
+	if baseDomain != base {
+		ref.BaseDomain = baseDomain
+	}
+
+	if ref.Area == "" {
+		return ref, fmt.Errorf("%w: %s", ErrNotCraigslistURL, link)
+	}
+
+	pieces := strings.Split(strings.Trim(u.Path, "/"), "/")
+	last := pieces[len(pieces)-1]
+	if !strings.HasSuffix(last, ".html") {
+		return ref, fmt.Errorf("listing url does not end with a post id: %s", link)
+	}
+	ref.PostID = strings.TrimSuffix(last, ".html")
+	if ref.PostID == "" || strings.Trim(ref.PostID, "0123456789") != "" {
+		return ref, fmt.Errorf("invalid post id in listing url: %s", link)
+	}
+	pieces = pieces[:len(pieces)-1]
+
+	if len(pieces) >= 2 && pieces[len(pieces)-2] == "d" {
+		ref.Slug = pieces[len(pieces)-1]
+		pieces = pieces[:len(pieces)-2]
+	}
+
+	switch len(pieces) {
+	case 1:
+		ref.Category = pieces[0]
+	case 2:
+		ref.SubArea = pieces[0]
+		ref.Category = pieces[1]
+	default:
+		return ref, fmt.Errorf("unexpected path in listing url: %s", link)
+	}
+
+	return ref, nil
+}
+
+// URL builds the posting link the ListingRef was parsed from.
+func (r ListingRef) URL() string {
+	domain := r.BaseDomain
+	if domain == "" {
+		domain = base
+	}
+
+	link := protocol + "://" + r.Area + "." + domain + "/"
+	if r.SubArea != "" {
+		link += r.SubArea + "/"
+	}
+
+	link += r.Category + "/"
+	if r.Slug != "" {
+		link += "d/" + r.Slug + "/"
+	}
+
+	return link + r.PostID + ".html"
+}
+
+// Ref returns the ListingRef of the listing's link.
+func (l Listing) Ref() ListingRef {
+	ref := ListingRef{
+		Area:     l.Area,
+		SubArea:  l.SubAreaAbbr,
+		Category: l.Category,
+		Slug:     l.Slug,
+		PostID:   l.DataPID,
+	}
+
+	if parsed, err := parseListingURL(l.Link, ""); err == nil {
+		ref.BaseDomain = parsed.BaseDomain
+	}
+
+	return ref
+}
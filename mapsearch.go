@@ -0,0 +1,243 @@
+package gocraigslist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const mapPath = "/jsonsearch/"
+
+// mapClusterDepth limits how deep clusters within clusters are followed.
+const mapClusterDepth = 5
+
+// mapThumbSize matches the size suffix on the thumbnail of a map pin.
+var mapThumbSize = regexp.MustCompile(`_\d+x\d+c?$`)
+
+// Bounds is a bounding box in degrees used to restrict map searches.
+type Bounds struct {
+	North float64
+	South float64
+	East  float64
+	West  float64
+}
+
+// Contains reports whether the coordinate lies within the box. Boxes whose
+// West is greater than their East wrap around the antimeridian.
+func (b Bounds) Contains(latitude float64, longitude float64) bool {
+	if latitude < b.South || latitude > b.North {
+		return false
+	}
+
+	if b.West <= b.East {
+		return longitude >= b.West && longitude <= b.East
+	}
+	return longitude >= b.West || longitude <= b.East
+}
+
+// mapPin is a single posting or a cluster of postings on the map view.
+// Clusters only carry a url to fetch the postings they group.
+type mapPin struct {
+	Ask              json.Number
+	ImageThumb       string
+	Latitude         float64
+	LocationAccuracy int
+	Longitude        float64
+	PostedDate       json.Number
+	PostingID        json.Number
+	PostingTitle     string
+	PostingURL       string
+	GeoCluster       string
+	NumPosts         int
+	URL              string `json:"url"`
+}
+
+// GetMapListings runs the search of a url built by FormatURL against the map
+// view, which returns every geocoded listing with its coordinates and location
+// accuracy instead of a page of the list view. When bounds is provided it is
+// sent with the request and listings outside of it are also dropped from the
+// response. The Result is never paginated.
+func (c *Client) GetMapListings(ctx context.Context, searchURL string, bounds *Bounds) (*Result, error) {
+	hostname, err := hostnameFromURL(searchURL, c.baseDomain())
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.loadAreas(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting timezones: %v", err)
+	}
+	timezone := c.TimezoneMap[hostname]
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	target, err := mapSearchURL(searchURL, bounds)
+	if err != nil {
+		return nil, fmt.Errorf("error building map search url: %v", err)
+	}
+
+	// clusters group postings that are close together, each has to be
+	// fetched separately to get at its postings and may hold more clusters.
+	type pending struct {
+		url   string
+		depth int
+	}
+	queue := []pending{{url: target}}
+	seen := map[string]bool{target: true}
+
+	listings := []Listing{}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		found, clusters, err := c.fetchMapPins(ctx, next.url, loc)
+		if err != nil {
+			return nil, err
+		}
+		listings = append(listings, found...)
+
+		if next.depth == mapClusterDepth {
+			continue
+		}
+
+		u, _ := url.Parse(next.url)
+		for _, cluster := range clusters {
+			clusterURL, err := u.Parse(cluster)
+			if err != nil {
+				return nil, fmt.Errorf("error building cluster url: %v", err)
+			}
+
+			if !seen[clusterURL.String()] {
+				seen[clusterURL.String()] = true
+				queue = append(queue, pending{url: clusterURL.String(), depth: next.depth + 1})
+			}
+		}
+	}
+
+	if bounds != nil {
+		inside := []Listing{}
+		for _, l := range listings {
+			if bounds.Contains(l.Latitude, l.Longitude) {
+				inside = append(inside, l)
+			}
+		}
+		listings = inside
+	}
+
+	tagNearby(listings, hostname)
+	c.resolveSubAreas(listings, hostname, searchSubArea(searchURL))
+
+	r := newResult(c, searchURL, len(listings), listings, timezone)
+	r.Done = true
+
+	return r, nil
+}
+
+func (c *Client) fetchMapPins(ctx context.Context, target string, loc *time.Location) ([]Listing, []string, error) {
+	resp, err := c.Request.fetch(ctx, target)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error sending http request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	listings, clusters, err := parseMapPins(resp.Body, loc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing map results: %v", err)
+	}
+
+	return listings, clusters, nil
+}
+
+// mapSearchURL points a search url at the map view's json endpoint, limited
+// to bounds when provided.
+func mapSearchURL(searchURL string, bounds *Bounds) (string, error) {
+	u, err := url.Parse(searchURL)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(u.Path, defPath) {
+		return "", fmt.Errorf("not a search url: %s", searchURL)
+	}
+	u.Path = mapPath + strings.TrimPrefix(u.Path, defPath)
+
+	if bounds != nil {
+		q := u.Query()
+		q.Set("max_lat", strconv.FormatFloat(bounds.North, 'f', -1, 64))
+		q.Set("min_lat", strconv.FormatFloat(bounds.South, 'f', -1, 64))
+		q.Set("max_lon", strconv.FormatFloat(bounds.East, 'f', -1, 64))
+		q.Set("min_lon", strconv.FormatFloat(bounds.West, 'f', -1, 64))
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String(), nil
+}
+
+// parseMapPins decodes a map view response, which is an array holding the
+// list of pins followed by a summary object. Postings are returned as
+// listings and clusters as the urls to fetch their postings from.
+func parseMapPins(data io.Reader, loc *time.Location) ([]Listing, []string, error) {
+	body := []json.RawMessage{}
+	err := json.NewDecoder(data).Decode(&body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to decode data: %v", err)
+	}
+
+	listings := []Listing{}
+	clusters := []string{}
+	if len(body) == 0 {
+		return listings, clusters, nil
+	}
+
+	pins := []mapPin{}
+	err = json.Unmarshal(body[0], &pins)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to decode pins: %v", err)
+	}
+
+	for _, pin := range pins {
+		if pin.GeoCluster != "" {
+			if pin.URL != "" {
+				clusters = append(clusters, pin.URL)
+			}
+			continue
+		}
+
+		newListing := Listing{
+			DataPID:          pin.PostingID.String(),
+			Title:            pin.PostingTitle,
+			Link:             pin.PostingURL,
+			Latitude:         pin.Latitude,
+			Longitude:        pin.Longitude,
+			LocationAccuracy: pin.LocationAccuracy,
+		}
+
+		if posted, err := pin.PostedDate.Int64(); err == nil {
+			newListing.Date = time.Unix(posted, 0).In(loc).Format("2006-01-02 15:04:05")
+		}
+
+		if pin.Ask != "" {
+			newListing.Price = "$" + pin.Ask.String()
+		}
+
+		if pin.ImageThumb != "" {
+			id := strings.TrimSuffix(path.Base(pin.ImageThumb), path.Ext(pin.ImageThumb))
+			newListing.ImageIDs = []string{mapThumbSize.ReplaceAllString(id, "")}
+		}
+
+		finishListing(&newListing)
+		listings = append(listings, newListing)
+	}
+
+	return listings, clusters, nil
+}
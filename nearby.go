@@ -0,0 +1,57 @@
+package gocraigslist
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// NearbyArea is an area craigslist offers to include in a search, as listed
+// on the search page.
+type NearbyArea struct {
+	AreaID       int    // the value used in Options.NearbyAreas, matches Area.AreaID
+	Name         string // example = albany, NY
+	Abbreviation string // example = alb
+}
+
+// parseNearbyAreas reads the nearby areas of a search page. Each match of
+// labels holds a checkbox with the area id and its name followed by the
+// abbreviation in parentheses.
+func parseNearbyAreas(doc *html.Node, labels selector) []NearbyArea {
+	inputs, _ := compileSelector("input")
+	smalls, _ := compileSelector("small")
+
+	areas := []NearbyArea{}
+	for _, label := range labels.all(doc) {
+		input, has := inputs.first(label)
+		if !has {
+			continue
+		}
+
+		_, value := findAttr(input.Attr, "value")
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+
+		area := NearbyArea{AreaID: id, Name: findText(label)}
+		if small, has := smalls.first(label); has {
+			abbreviation := findText(small)
+			area.Name = strings.TrimSpace(strings.TrimSuffix(area.Name, abbreviation))
+			area.Abbreviation = strings.Trim(abbreviation, "()")
+		}
+
+		areas = append(areas, area)
+	}
+
+	return areas
+}
+
+// tagNearby flags the listings posted in an area other than the one
+// searched, which only happens when nearby areas are included.
+func tagNearby(listings []Listing, hostname string) {
+	for i := range listings {
+		listings[i].Nearby = listings[i].Area != "" && listings[i].Area != hostname
+	}
+}
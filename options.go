@@ -0,0 +1,236 @@
+package gocraigslist
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidOptions is wrapped by every error returned from Options.Validate.
+var ErrInvalidOptions = errors.New("invalid options")
+
+// Validate reports the first option FormatURL cannot encode: categories
+// that are neither in DefaultCategories nor shaped like a three letter code,
+// NearbyAreas without IncludeNearby or with ids that are not positive,
+// unknown sort orders, conditions or languages, prices that are not whole
+// numbers, a MinPrice above MaxPrice, a search distance that is not a whole
+// number or has nothing to be measured from, coordinates out of range,
+// unknown or out of order AutoOptions, HousingOptions and JobOptions
+// filters, AutoOptions outside of the cars and trucks categories and
+// PostedBy on a category without a known owner or dealer variant.
+func (o Options) Validate() error {
+	return o.validate(DefaultCategories)
+}
+
+func (o Options) validate(catalog []Category) error {
+	code := categoryCode(catalog, o.Category)
+	if code != "" {
+		if _, has := categoryByCode(catalog, code); !has && !categoryCodePattern.MatchString(code) {
+			return fmt.Errorf("%w: unknown category %q", ErrInvalidOptions, o.Category)
+		}
+	}
+
+	seller := o.postedBy()
+	if seller.Code() == "" {
+		return fmt.Errorf("%w: unknown posted by %q, expected owner or dealer", ErrInvalidOptions, seller)
+	}
+
+	if o.Seller != "" && o.PostedBy != "" {
+		if legacy, err := ParsePostedBy(o.PostedBy); err != nil || legacy != o.Seller {
+			return fmt.Errorf("%w: posted by %q conflicts with seller %q", ErrInvalidOptions, o.PostedBy, o.Seller)
+		}
+	}
+
+	if seller != "" && seller != PostedByAll && code != "" {
+		category, _ := categoryByCode(catalog, code)
+		if category.Variant(seller) == "" {
+			return fmt.Errorf("%w: category %q has no by %s variant", ErrInvalidOptions, o.Category, seller)
+		}
+	}
+
+	if len(o.NearbyAreas) > 0 && !o.IncludeNearby {
+		return fmt.Errorf("%w: nearby areas require IncludeNearby", ErrInvalidOptions)
+	}
+
+	for _, id := range o.NearbyAreas {
+		if id <= 0 {
+			return fmt.Errorf("%w: invalid nearby area id %d", ErrInvalidOptions, id)
+		}
+	}
+
+	if o.Sort.Code() == "" {
+		return fmt.Errorf("%w: unknown sort order %q", ErrInvalidOptions, o.Sort)
+	}
+
+	for _, c := range o.conditions() {
+		if c.Code() == "" {
+			return fmt.Errorf("%w: unknown condition %q", ErrInvalidOptions, c)
+		}
+	}
+
+	for _, l := range o.languages() {
+		if l.Code() == "" {
+			return fmt.Errorf("%w: unknown language %q", ErrInvalidOptions, l)
+		}
+	}
+
+	err := validateRange("price", o.MinPrice, o.MaxPrice)
+	if err != nil {
+		return err
+	}
+
+	_, err = parseWholeNumber("search distance", o.SearchDistance)
+	if err != nil {
+		return err
+	}
+
+	if o.SearchDistance != "" && o.Postal == "" && o.Latitude == "" && o.Longitude == "" {
+		return fmt.Errorf("%w: search distance requires a postal code or latitude and longitude", ErrInvalidOptions)
+	}
+
+	if (o.Latitude == "") != (o.Longitude == "") {
+		return fmt.Errorf("%w: latitude and longitude must be provided together", ErrInvalidOptions)
+	}
+
+	err = parseCoordinate("latitude", o.Latitude, 90)
+	if err != nil {
+		return err
+	}
+
+	err = parseCoordinate("longitude", o.Longitude, 180)
+	if err != nil {
+		return err
+	}
+
+	err = o.Auto.validate(code)
+	if err != nil {
+		return err
+	}
+
+	err = o.Housing.validate()
+	if err != nil {
+		return err
+	}
+
+	err = o.Jobs.validate()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// postedBy is Seller, or PostedBy by name or category code when Seller is
+// not set. Unknown values are kept as is for Validate to report.
+func (o Options) postedBy() PostedBy {
+	if o.Seller != "" {
+		return o.Seller
+	}
+
+	if seller, err := ParsePostedBy(o.PostedBy); err == nil {
+		return seller
+	}
+	return PostedBy(o.PostedBy)
+}
+
+// conditions merges the Condition names with the typed Conditions.
+func (o Options) conditions() []Condition {
+	conditions := []Condition{}
+	for _, c := range o.Condition {
+		conditions = append(conditions, Condition(c))
+	}
+	return append(conditions, o.Conditions...)
+}
+
+// languages merges the Language names with the typed Languages.
+func (o Options) languages() []Language {
+	languages := []Language{}
+	for _, l := range o.Language {
+		languages = append(languages, Language(l))
+	}
+	return append(languages, o.Languages...)
+}
+
+// validateRange checks both ends of an optional range are whole numbers and
+// in order.
+func validateRange(name string, minValue string, maxValue string) error {
+	min, err := parseWholeNumber("min "+name, minValue)
+	if err != nil {
+		return err
+	}
+
+	max, err := parseWholeNumber("max "+name, maxValue)
+	if err != nil {
+		return err
+	}
+
+	if minValue != "" && maxValue != "" && min > max {
+		return fmt.Errorf("%w: min %s %d is greater than max %s %d", ErrInvalidOptions, name, min, name, max)
+	}
+
+	return nil
+}
+
+// validateDecimalRange is validateRange for ranges that allow fractions.
+func validateDecimalRange(name string, minValue string, maxValue string) error {
+	min, err := parseDecimal("min "+name, minValue)
+	if err != nil {
+		return err
+	}
+
+	max, err := parseDecimal("max "+name, maxValue)
+	if err != nil {
+		return err
+	}
+
+	if minValue != "" && maxValue != "" && min > max {
+		return fmt.Errorf("%w: min %s %v is greater than max %s %v", ErrInvalidOptions, name, min, name, max)
+	}
+
+	return nil
+}
+
+// parseDecimal parses an optional non negative number written with digits
+// and at most one decimal point, an empty value is zero.
+func parseDecimal(name string, value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || strings.Trim(value, "0123456789.") != "" {
+		return 0, fmt.Errorf("%w: %s %q is not a number", ErrInvalidOptions, name, value)
+	}
+
+	return n, nil
+}
+
+// parseWholeNumber parses an optional non negative integer option, an empty
+// value is zero.
+func parseWholeNumber(name string, value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 || strings.TrimSpace(value) != value {
+		return 0, fmt.Errorf("%w: %s %q is not a whole number", ErrInvalidOptions, name, value)
+	}
+
+	return n, nil
+}
+
+// parseCoordinate checks an optional coordinate in degrees is within limit.
+func parseCoordinate(name string, value string, limit float64) error {
+	if value == "" {
+		return nil
+	}
+
+	degrees, err := strconv.ParseFloat(value, 64)
+	if err != nil || degrees < -limit || degrees > limit {
+		return fmt.Errorf("%w: %s %q is not between -%v and %v", ErrInvalidOptions, name, value, limit, limit)
+	}
+
+	return nil
+}
@@ -0,0 +1,254 @@
+package gocraigslist
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateOptions(t *testing.T) {
+	t.Run("should accept valid options", func(t *testing.T) {
+		for _, given := range []Options{
+			{},
+			{PostedBy: "owner"},
+			{PostedBy: "dealer", Category: "sss"},
+			{PostedBy: "owner", Category: "cta"},
+			{PostedBy: "dealer", Category: "mca"},
+			{PostedBy: "all", Category: "apa"},
+			{Category: "cta", MinPrice: "100"},
+			{MinPrice: "100", MaxPrice: "100"},
+			{MaxPrice: "500"},
+			{Sort: SortDate},
+			{IncludeNearby: true, NearbyAreas: []int{59, 355}},
+			{Category: "jjj", Jobs: JobOptions{EmploymentType: []EmploymentType{EmploymentFullTime}, Telecommuting: true}},
+			{Category: "apa", Housing: HousingOptions{MinBedrooms: "2", MaxSqft: "900", DogsOK: true, Parking: []Parking{ParkingCarport}}},
+			{Category: "apa", Housing: HousingOptions{MinBathrooms: "1.5", MaxBathrooms: "2"}},
+			{Category: "cta", Auto: AutoOptions{MinYear: "2010", MaxYear: "2010", MinMiles: "0", BodyType: []BodyType{BodyPickup}}},
+			{Category: "cta", Seller: PostedByOwner, Auto: AutoOptions{FuelType: []FuelType{FuelElectric}}},
+			{SearchDistance: "25", Postal: "10001"},
+			{SearchDistance: "5", Latitude: "40.7506", Longitude: "-73.9971"},
+			{Condition: []string{"new", "like new"}, Language: []string{"en", "vi"}},
+			{Conditions: []Condition{ConditionGood}, Languages: []Language{LanguageDE}, Seller: PostedByOwner},
+			{PostedBy: "sso", Seller: PostedByOwner},
+		} {
+			assert.NoError(t, given.Validate(), "%+v", given)
+		}
+	})
+
+	t.Run("should reject invalid options", func(t *testing.T) {
+		for _, test := range []struct {
+			given    Options
+			expected string
+		}{
+			{given: Options{Condition: []string{"new", "mint"}}, expected: `invalid options: unknown condition "mint"`},
+			{given: Options{Sort: "newest"}, expected: `invalid options: unknown sort order "newest"`},
+			{given: Options{Language: []string{"xx"}}, expected: `invalid options: unknown language "xx"`},
+			{given: Options{MinPrice: "cheap"}, expected: `invalid options: min price "cheap" is not a whole number`},
+			{given: Options{MaxPrice: "$500"}, expected: `invalid options: max price "$500" is not a whole number`},
+			{given: Options{MinPrice: "-1"}, expected: `invalid options: min price "-1" is not a whole number`},
+			{given: Options{MinPrice: "500", MaxPrice: "100"}, expected: "invalid options: min price 500 is greater than max price 100"},
+			{given: Options{PostedBy: "owner", Category: "apa"}, expected: `invalid options: category "apa" has no by owner variant`},
+			{given: Options{PostedBy: "dealer", Category: "zip"}, expected: `invalid options: category "zip" has no by dealer variant`},
+			{given: Options{SearchDistance: "far", Postal: "10001"}, expected: `invalid options: search distance "far" is not a whole number`},
+			{given: Options{SearchDistance: "25"}, expected: "invalid options: search distance requires a postal code or latitude and longitude"},
+			{given: Options{Latitude: "40.7506"}, expected: "invalid options: latitude and longitude must be provided together"},
+			{given: Options{Latitude: "91", Longitude: "0"}, expected: `invalid options: latitude "91" is not between -90 and 90`},
+			{given: Options{Latitude: "0", Longitude: "east"}, expected: `invalid options: longitude "east" is not between -180 and 180`},
+			{given: Options{Category: "cta", Auto: AutoOptions{MinYear: "2015", MaxYear: "2010"}}, expected: "invalid options: min year 2015 is greater than max year 2010"},
+			{given: Options{Category: "cta", Auto: AutoOptions{MaxMiles: "90k"}}, expected: `invalid options: max miles "90k" is not a whole number`},
+			{given: Options{Category: "cta", Auto: AutoOptions{FuelType: []FuelType{"steam"}}}, expected: `invalid options: unknown fuel type "steam"`},
+			{given: Options{Category: "cta", Auto: AutoOptions{PaintColor: []PaintColor{"teal"}}}, expected: `invalid options: unknown paint color "teal"`},
+			{given: Options{Auto: AutoOptions{MinYear: "2010"}}, expected: `invalid options: auto filters require the cta, cto or ctd category, not ""`},
+			{given: Options{Category: "apa", Auto: AutoOptions{BodyType: []BodyType{BodySedan}}}, expected: `invalid options: auto filters require the cta, cto or ctd category, not "apa"`},
+			{given: Options{Housing: HousingOptions{MinBedrooms: "3", MaxBedrooms: "1"}}, expected: "invalid options: min bedrooms 3 is greater than max bedrooms 1"},
+			{given: Options{Housing: HousingOptions{MinBathrooms: "2.5", MaxBathrooms: "2"}}, expected: "invalid options: min bathrooms 2.5 is greater than max bathrooms 2"},
+			{given: Options{Housing: HousingOptions{MaxBathrooms: "1.5.1"}}, expected: `invalid options: max bathrooms "1.5.1" is not a number`},
+			{given: Options{Housing: HousingOptions{MaxBathrooms: "-1"}}, expected: `invalid options: max bathrooms "-1" is not a number`},
+			{given: Options{Housing: HousingOptions{MaxSqft: "1,200"}}, expected: `invalid options: max sqft "1,200" is not a whole number`},
+			{given: Options{Housing: HousingOptions{Laundry: []Laundry{"river"}}}, expected: `invalid options: unknown laundry "river"`},
+			{given: Options{Housing: HousingOptions{Parking: []Parking{"moat"}}}, expected: `invalid options: unknown parking "moat"`},
+			{given: Options{Housing: HousingOptions{Availability: "tomorrow"}}, expected: `invalid options: unknown availability "tomorrow"`},
+			{given: Options{Jobs: JobOptions{EmploymentType: []EmploymentType{"seasonal"}}}, expected: `invalid options: unknown employment type "seasonal"`},
+			{given: Options{Jobs: JobOptions{Pay: "maybe"}}, expected: `invalid options: unknown gig pay "maybe"`},
+			{given: Options{NearbyAreas: []int{59}}, expected: "invalid options: nearby areas require IncludeNearby"},
+			{given: Options{IncludeNearby: true, NearbyAreas: []int{0}}, expected: "invalid options: invalid nearby area id 0"},
+			{given: Options{PostedBy: "private"}, expected: `invalid options: unknown posted by "private", expected owner or dealer`},
+			{given: Options{Conditions: []Condition{"mint"}}, expected: `invalid options: unknown condition "mint"`},
+			{given: Options{Seller: PostedByOwner, Category: "apa"}, expected: `invalid options: category "apa" has no by owner variant`},
+			{given: Options{PostedBy: "dealer", Seller: PostedByOwner}, expected: `invalid options: posted by "dealer" conflicts with seller "owner"`},
+		} {
+			err := test.given.Validate()
+			assert.True(t, errors.Is(err, ErrInvalidOptions), "%+v", test.given)
+			assert.EqualError(t, err, test.expected)
+		}
+	})
+}
+
+func TestBuildURL(t *testing.T) {
+	client := NewClient("newyork").(*Client)
+
+	t.Run("should build the same url as FormatURL", func(t *testing.T) {
+		o := Options{PostedBy: "owner", MinPrice: "100", Condition: []string{"good"}}
+
+		url, err := client.BuildURL("xbox", o)
+		assert.NoError(t, err)
+		assert.Equal(t, client.FormatURL("xbox", o), url)
+	})
+
+	t.Run("should not build a url for invalid options", func(t *testing.T) {
+		url, err := client.BuildURL("xbox", Options{Condition: []string{"mint"}})
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+		assert.Equal(t, "", url)
+	})
+}
+
+func TestEnums(t *testing.T) {
+	t.Run("should map names to craigslist codes", func(t *testing.T) {
+		assert.Equal(t, "20", ConditionLikeNew.Code())
+		assert.Equal(t, "4", LanguageDE.Code())
+		assert.Equal(t, "sso", PostedByOwner.Code())
+		assert.Equal(t, "sss", PostedBy("").Code())
+		assert.Equal(t, "date", SortDate.Code())
+		assert.Equal(t, "rel", SortOrder("").Code())
+		assert.Equal(t, "", Condition("mint").Code())
+		assert.Equal(t, "like new", ConditionLikeNew.String())
+	})
+
+	t.Run("should parse names and codes", func(t *testing.T) {
+		for _, given := range []string{"like new", "Like New", "20"} {
+			c, err := ParseCondition(given)
+			assert.NoError(t, err, given)
+			assert.Equal(t, ConditionLikeNew, c, given)
+		}
+
+		for _, given := range []string{"de", "DE", "4"} {
+			l, err := ParseLanguage(given)
+			assert.NoError(t, err, given)
+			assert.Equal(t, LanguageDE, l, given)
+		}
+
+		for _, given := range []string{"dealer", "ssq"} {
+			p, err := ParsePostedBy(given)
+			assert.NoError(t, err, given)
+			assert.Equal(t, PostedByDealer, p, given)
+		}
+
+		for _, given := range []string{"relevance", "rel"} {
+			s, err := ParseSortOrder(given)
+			assert.NoError(t, err, given)
+			assert.Equal(t, SortRelevance, s, given)
+		}
+
+		_, err := ParseCondition("mint")
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+		_, err = ParseLanguage("xx")
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+		_, err = ParsePostedBy("private")
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+		_, err = ParseSortOrder("random")
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+	})
+
+	t.Run("should marshal to names and unmarshal names or codes", func(t *testing.T) {
+		given := Options{
+			Seller:     PostedByOwner,
+			Conditions: []Condition{ConditionNew, ConditionLikeNew},
+			Languages:  []Language{LanguageEN},
+		}
+
+		data, err := json.Marshal(given)
+		assert.NoError(t, err)
+
+		decoded := Options{}
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, given, decoded)
+
+		decoded = Options{}
+		err = json.Unmarshal([]byte(`{"Seller":"sso","Conditions":[10,"20"],"Languages":["EN"]}`), &decoded)
+		assert.NoError(t, err)
+		assert.Equal(t, given, decoded)
+
+		var sort SortOrder
+		assert.NoError(t, json.Unmarshal([]byte(`"pricedsc"`), &sort))
+		assert.Equal(t, SortPriceDesc, sort)
+
+		err = json.Unmarshal([]byte(`{"Conditions":["mint"]}`), &decoded)
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+	})
+
+	t.Run("should round trip the auto filters", func(t *testing.T) {
+		given := Options{Auto: AutoOptions{
+			TitleStatus:  []TitleStatus{TitleClean},
+			Transmission: []Transmission{TransmissionManual},
+			FuelType:     []FuelType{FuelHybrid},
+			Drivetrain:   []Drivetrain{Drivetrain4WD},
+			Cylinders:    []Cylinders{Cylinders6},
+			BodyType:     []BodyType{BodySUV},
+			PaintColor:   []PaintColor{PaintBrown},
+		}}
+
+		data, err := json.Marshal(given)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `"BodyType":["SUV"]`)
+
+		decoded := Options{}
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, given, decoded)
+
+		decoded = Options{}
+		err = json.Unmarshal([]byte(`{"Auto": {"TitleStatus":[1],"Transmission":["1"],"FuelType":["Hybrid"],"Drivetrain":["4WD"],"Cylinders":[4],"BodyType":["suv"],"PaintColor":[20]}}`), &decoded)
+		assert.NoError(t, err)
+		assert.Equal(t, given, decoded)
+
+		body, err := ParseBodyType("10")
+		assert.NoError(t, err)
+		assert.Equal(t, "SUV", body.String())
+
+		_, err = ParseFuelType("steam")
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+	})
+
+	t.Run("should round trip the housing filters", func(t *testing.T) {
+		given := Options{Housing: HousingOptions{
+			Laundry:      []Laundry{LaundryInUnit},
+			Parking:      []Parking{ParkingStreet},
+			Availability: AvailableWithin30Days,
+		}}
+
+		data, err := json.Marshal(given)
+		assert.NoError(t, err)
+
+		decoded := Options{}
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, given, decoded)
+
+		decoded = Options{}
+		err = json.Unmarshal([]byte(`{"Housing": {"Laundry":[1],"Parking":["Street Parking"],"Availability":"1"}}`), &decoded)
+		assert.NoError(t, err)
+		assert.Equal(t, given, decoded)
+
+		_, err = ParseParking("helipad")
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+	})
+
+	t.Run("should round trip the job filters", func(t *testing.T) {
+		given := Options{Jobs: JobOptions{EmploymentType: []EmploymentType{EmploymentContract}, Pay: GigUnpaid}}
+
+		data, err := json.Marshal(given)
+		assert.NoError(t, err)
+
+		decoded := Options{}
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, given, decoded)
+
+		decoded = Options{}
+		err = json.Unmarshal([]byte(`{"Jobs": {"EmploymentType":[3],"Pay":"no"}}`), &decoded)
+		assert.NoError(t, err)
+		assert.Equal(t, given, decoded)
+
+		_, err = ParseEmploymentType("seasonal")
+		assert.True(t, errors.Is(err, ErrInvalidOptions))
+	})
+}
@@ -1,6 +1,7 @@
 package gocraigslist
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"strconv"
@@ -12,59 +13,191 @@ import (
 
 // Listing represents a craigslist listing as a struct
 type Listing struct {
-	DataPID      string
-	DataRepostOf string
-	Date         string
-	Title        string
-	Link         string
-	Price        string
-	Hood         string
+	DataPID          string
+	DataRepostOf     string
+	Date             string
+	Title            string
+	Link             string
+	Price            string
+	Hood             string   // neighborhood without the surrounding parentheses
+	Area             string   // hostname of the area from the link, see ListingRef
+	SubAreaAbbr      string   // subarea code from the link (brx, que), empty when the area has none
+	SubArea          *SubArea // SubAreaAbbr resolved against the Areas reference data, nil when unknown
+	Nearby           bool     // posted in an area other than the one searched, see Options.IncludeNearby
+	Category         string   // category code from the link
+	Slug             string   // title slug from the link
+	Bedrooms         int      // housing only, 0 when not listed
+	SquareFeet       int      // housing only, converted when listed in m2, 0 when not listed
+	SquareMeters     int      // housing only, converted when listed in ft2, 0 when not listed
+	Description      string   // only provided by the rss backend
+	Latitude         float64  // only provided by backends that expose coordinates
+	Longitude        float64  // only provided by backends that expose coordinates
+	LocationAccuracy int      // only provided by map searches, larger is less precise
+	ImageIDs         []string // craigslist image ids, see ImageURLs
 }
 
-var nilTime = time.Time{}
+// ImageURLs builds the full size image url for each of the listing's ImageIDs.
+func (l Listing) ImageURLs() []string {
+	urls := []string{}
+	for _, id := range l.ImageIDs {
+		urls = append(urls, imageURL(id))
+	}
+	return urls
+}
 
-func parseSearchResults(data io.Reader) ([]Listing, int, error) {
-	doc, err := html.Parse(data)
+func imageURL(id string) string {
+	return imageBase + id + "_600x450.jpg"
+}
+
+// finishListing fills the fields every parser derives the same way from the
+// ones it extracted.
+func finishListing(l *Listing) {
+	l.Hood = normalizeHood(l.Hood)
+
+	ref, err := parseListingURL(l.Link, "")
 	if err != nil {
-		return nil, 0, fmt.Errorf("unable to parse data: %v", err)
+		return
 	}
 
-	// find the entrypoint to  the results section of the page
-	resultSection, _ := findBy(doc, "id", "sortable-results")
-	// find the resultList, everything in here will go into the listing slice
-	resultList, _ := findBy(resultSection, "class", "rows")
+	l.Area = ref.Area
+	l.SubAreaAbbr = ref.SubArea
+	l.Category = ref.Category
+	l.Slug = ref.Slug
+	if l.DataPID == "" {
+		l.DataPID = ref.PostID
+	}
+}
 
-	listings := extractListings(resultList, nilTime)
+// normalizeHood turns craigslist's " (Throgs Neck)" style hood into "Throgs Neck".
+func normalizeHood(hood string) string {
+	hood = strings.Join(strings.Fields(hood), " ")
+	hood = strings.TrimPrefix(hood, "(")
+	hood = strings.TrimSuffix(hood, ")")
+	return strings.Trim(hood, " ,;-")
+}
 
-	totalCountSection, _ := findBy(doc, "class", "totalcount")
-	totalCount, err := strconv.Atoi(findText(totalCountSection))
-	if err != nil {
-		return listings, 0, fmt.Errorf("unable to parse count: %v", err)
+// parseImageIDs strips the "N:" prefix craigslist adds to the image ids
+// on both the data-ids attribute and the json api.
+func parseImageIDs(ids []string) []string {
+	parsed := []string{}
+	for _, id := range ids {
+		if index := strings.Index(id, ":"); index >= 0 {
+			id = id[index+1:]
+		}
+		if id != "" {
+			parsed = append(parsed, id)
+		}
+	}
+	return parsed
+}
+
+const imageBase = "https://images.craigslist.org/"
+
+var nilTime = time.Time{}
+
+// resultDateLayout is the layout of Listing.Date.
+const resultDateLayout = "2006-01-02 15:04:05"
+
+// Parser turns a single page of search results into listings. Unless one is
+// provided with WithParser the Client picks one with DetectParser.
+type Parser interface {
+	Parse(data io.Reader, options ParseOptions) (*Page, error)
+}
+
+// ParseOptions carries the context of a search a Parser may need.
+type ParseOptions struct {
+	Cutoff     time.Time      // when non zero, stop at the first listing posted before Cutoff
+	Category   string         // category code of the search, used to build links the json api omits
+	Location   *time.Location // timezone of the searched area, defaults to UTC
+	Selectors  Selectors      // overrides for the html layout, empty fields use DefaultSelectors
+	BaseDomain string         // domain of links the json api omits, craigslist.org when empty
+}
+
+// Page is a single parsed page of search results.
+type Page struct {
+	Listings   []Listing
+	TotalCount int
+	RangeFrom  int    // position of the first listing within the whole search, 0 when unknown
+	RangeTo    int    // position of the last listing within the whole search, 0 when unknown
+	NextPage   string // href of the next page, empty on the last page or when unknown
+
+	// NearbyAreas are the areas that can be added to the search with
+	// Options.NearbyAreas, only listed by the legacy layout.
+	NearbyAreas []NearbyArea
+}
+
+// DetectParser picks the Parser for a search response from its content type,
+// falling back to the markup itself when the content type is ambiguous.
+func DetectParser(contentType string, data []byte) Parser {
+	contentType = strings.ToLower(contentType)
+	switch {
+	case strings.Contains(contentType, "json"):
+		return &JSONParser{}
+	case strings.Contains(contentType, "xml"), strings.Contains(contentType, "rss"):
+		return &RSSParser{}
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return &JSONParser{}
+	case bytes.HasPrefix(trimmed, []byte("<?xml")), bytes.Contains(trimmed, []byte("<rdf:RDF")):
+		return &RSSParser{}
+	case bytes.Contains(trimmed, []byte("cl-static-search-result")):
+		return &StaticParser{}
 	}
 
-	return listings, totalCount, nil
+	return &LegacyParser{}
 }
 
-func parseSearchResultsAfter(data io.Reader, date time.Time) ([]Listing, int, error) {
+// LegacyParser parses the html search page built around the sortable-results
+// section and result-row list items, located with ParseOptions.Selectors.
+type LegacyParser struct{}
+
+// Parse implements Parser.
+func (p *LegacyParser) Parse(data io.Reader, options ParseOptions) (*Page, error) {
 	doc, err := html.Parse(data)
 	if err != nil {
-		return nil, 0, fmt.Errorf("unable to parse data: %v", err)
+		return nil, fmt.Errorf("unable to parse data: %v", err)
+	}
+
+	// an invalid override is a caller error, not a layout change
+	sel, err := options.Selectors.compile()
+	if err != nil {
+		return nil, err
 	}
 
 	// find the entrypoint to  the results section of the page
-	resultSection, _ := findBy(doc, "id", "sortable-results")
+	resultSection, _ := sel.Results.first(doc)
 	// find the resultList, everything in here will go into the listing slice
-	resultList, _ := findBy(resultSection, "class", "rows")
+	resultList, _ := sel.Rows.first(resultSection)
+	totalCountSection, _ := sel.TotalCount.first(doc)
+
+	err = validateLegacyLayout(resultSection, resultList, totalCountSection, sel)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Listings: extractListings(resultList, options.Cutoff, sel)}
 
-	listings := extractListings(resultList, date)
+	rangeFromSection, _ := sel.RangeFrom.first(doc)
+	page.RangeFrom, _ = strconv.Atoi(findText(rangeFromSection))
+	rangeToSection, _ := sel.RangeTo.first(doc)
+	page.RangeTo, _ = strconv.Atoi(findText(rangeToSection))
+
+	nextSection, _ := sel.NextPage.first(doc)
+	if nextSection != nil {
+		_, page.NextPage = findAttr(nextSection.Attr, "href")
+	}
 
-	totalCountSection, _ := findBy(doc, "class", "totalcount")
-	totalCount, err := strconv.Atoi(findText(totalCountSection))
+	page.NearbyAreas = parseNearbyAreas(doc, sel.NearbyArea)
+
+	page.TotalCount, err = strconv.Atoi(findText(totalCountSection))
 	if err != nil {
-		return listings, 0, fmt.Errorf("unable to parse count: %v", err)
+		return page, fmt.Errorf("unable to parse count: %v", err)
 	}
 
-	return listings, totalCount, nil
+	return page, nil
 }
 
 // findBy takes a parent node and iterates recursevly through the nodes
@@ -95,6 +228,16 @@ func findBy(n *html.Node, attrKey string, attrName string) (*html.Node, bool) {
 	return nil, false
 }
 
+// findByWithin is findBy limited to the descendants of n, findBy itself
+// also walks the siblings that follow n.
+func findByWithin(n *html.Node, attrKey string, attrName string) (*html.Node, bool) {
+	if n == nil || n.FirstChild == nil {
+		return nil, false
+	}
+
+	return findBy(n.FirstChild, attrKey, attrName)
+}
+
 // findAttr will take in the list of attributes and pull the specific one needed
 func findAttr(attributes []html.Attribute, targetName string) (name string, val string) {
 	for _, attr := range attributes {
@@ -106,31 +249,30 @@ func findAttr(attributes []html.Attribute, targetName string) (name string, val
 	return "", ""
 }
 
-// Text is stored as data on a TextType node as a child of the parent
-// that it is contained in. This function will go all the way down
-// checking node types. When FirstChild and NextSibling are both nil
-// as well as the correct type, return the data
+// findText collects the text of every text node below n, so inline markup
+// like <b> or <sup> does not cut it short. Runs of whitespace, including the
+// newlines of wrapped markup, are collapsed to a single space.
 func findText(n *html.Node) (text string) {
 	if n == nil {
 		return ""
 	}
 
-	if n.FirstChild != nil {
-		return findText(n.FirstChild)
-	}
-
-	if n.NextSibling != nil {
-		return findText(n.NextSibling)
-	}
-
-	if n.Type == html.TextNode {
-		return n.Data
+	var b strings.Builder
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			b.WriteString(node.Data)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
 	}
+	collect(n)
 
-	return ""
+	return strings.Join(strings.Fields(b.String()), " ")
 }
 
-func extractListings(item *html.Node, cutoffDate time.Time) []Listing {
+func extractListings(item *html.Node, cutoffDate time.Time, sel compiledSelectors) []Listing {
 	listings := []Listing{}
 	current := item.FirstChild
 
@@ -145,7 +287,7 @@ func extractListings(item *html.Node, cutoffDate time.Time) []Listing {
 		}
 
 		// all data housed under this node
-		info, _ := findBy(current, "class", "result-info")
+		info, _ := sel.Info.first(current)
 
 		if info == nil {
 			current = current.NextSibling
@@ -156,40 +298,44 @@ func extractListings(item *html.Node, cutoffDate time.Time) []Listing {
 		_, dataPID := findAttr(current.Attr, "data-pid")
 		_, dataRepostOf := findAttr(current.Attr, "data-repost-of")
 
-		datetimeNode, _ := findBy(info, "class", "result-date")
-		_, shortdate := findAttr(datetimeNode.Attr, "datetime")
-		_, longdate := findAttr(datetimeNode.Attr, "title")
-
-		longtime := strings.Split(longdate, " ")[3]
-		longtimeseconds := strings.Split(longtime, ":")[2]
-
-		shortdatetime := strings.Split(shortdate, " ")
-
-		datetime := strings.Join([]string{shortdatetime[0], shortdatetime[1] + ":" + longtimeseconds}, " ")
+		datetimeNode, _ := sel.Date.first(info)
+		datetime, ok := parseResultDate(datetimeNode)
+		if !ok {
+			current = current.NextSibling
+			continue
+		}
 
 		if cutoffDate != nilTime {
-			layout := "2006-01-02 15:04:05"
-			tz := cutoffDate.Location()
-			t, err := time.ParseInLocation(layout, datetime, tz)
-			if err != nil {
-				panic(err)
-			}
-
+			// parseResultDate has already checked the layout
+			t, _ := time.ParseInLocation(resultDateLayout, datetime, cutoffDate.Location())
 			if t.Before(cutoffDate) {
 				break
 			}
 		}
 
-		linkNode, _ := findBy(info, "class", "result-title hdrlnk")
-		_, link := findAttr(linkNode.Attr, "href")
+		var link string
+		linkNode, _ := sel.Title.first(info)
+		if linkNode != nil {
+			_, link = findAttr(linkNode.Attr, "href")
+		}
 		title := findText(linkNode)
 
-		priceNode, _ := findBy(info, "class", "result-price")
+		priceNode, _ := sel.Price.first(info)
 		price := findText(priceNode)
 
-		hoodNode, _ := findBy(info, "class", "result-hood")
+		hoodNode, _ := sel.Hood.first(info)
 		hood := findText(hoodNode)
 
+		housingNode, _ := sel.Housing.first(info)
+		bedrooms, squareFeet, squareMeters := parseHousing(findText(housingNode))
+
+		var imageIDs []string
+		imageNode, _ := sel.Images.first(current)
+		if imageNode != nil {
+			_, ids := findAttr(imageNode.Attr, "data-ids")
+			imageIDs = parseImageIDs(strings.Split(ids, ","))
+		}
+
 		newListing := Listing{
 			DataPID:      dataPID,
 			DataRepostOf: dataRepostOf,
@@ -198,7 +344,12 @@ func extractListings(item *html.Node, cutoffDate time.Time) []Listing {
 			Link:         link,
 			Price:        price,
 			Hood:         hood,
+			Bedrooms:     bedrooms,
+			SquareFeet:   squareFeet,
+			SquareMeters: squareMeters,
+			ImageIDs:     imageIDs,
 		}
+		finishListing(&newListing)
 
 		listings = append(listings, newListing)
 		current = current.NextSibling
@@ -206,3 +357,38 @@ func extractListings(item *html.Node, cutoffDate time.Time) []Listing {
 
 	return listings
 }
+
+// parseResultDate combines the minute precision datetime attribute with the
+// seconds found in the title attribute of a result-date node. It reports
+// false when the pieces do not make a valid date.
+func parseResultDate(n *html.Node) (string, bool) {
+	if n == nil {
+		return "", false
+	}
+
+	_, shortdate := findAttr(n.Attr, "datetime")
+	_, longdate := findAttr(n.Attr, "title")
+
+	longpieces := strings.Split(longdate, " ")
+	if len(longpieces) < 4 {
+		return "", false
+	}
+
+	longtimepieces := strings.Split(longpieces[3], ":")
+	if len(longtimepieces) < 3 {
+		return "", false
+	}
+	longtimeseconds := longtimepieces[2]
+
+	shortdatetime := strings.Split(shortdate, " ")
+	if len(shortdatetime) < 2 {
+		return "", false
+	}
+
+	datetime := strings.Join([]string{shortdatetime[0], shortdatetime[1] + ":" + longtimeseconds}, " ")
+	if _, err := time.Parse(resultDateLayout, datetime); err != nil {
+		return "", false
+	}
+
+	return datetime, true
+}
@@ -2,7 +2,9 @@ package gocraigslist
 
 import (
 	"bytes"
+	"errors"
 	"io/ioutil"
+	"strings"
 	"testing"
 	"time"
 
@@ -42,6 +44,140 @@ func TestFindBy(t *testing.T) {
 	}
 }
 
+func TestQuerySelector(t *testing.T) {
+	data, err := ioutil.ReadFile("./test.html")
+	assert.NoError(t, err)
+
+	doc, err := html.Parse(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		name     string
+		selector string
+		expected string // data-pid of the matched row, or the text when not a row
+		found    bool
+		invalid  bool
+	}{
+		{
+			name:     "by id",
+			selector: "#sortable-results",
+			found:    true,
+		},
+		{
+			name:     "by class token",
+			selector: ".result-row",
+			expected: "7132606866",
+			found:    true,
+		},
+		{
+			name:     "by tag, class and attribute",
+			selector: `li.result-row[data-repost-of="7106703240"]`,
+			expected: "7138122864",
+			found:    true,
+		},
+		{
+			name:     "by attribute presence",
+			selector: "li[data-repost-of]",
+			expected: "7138122864",
+			found:    true,
+		},
+		{
+			name:     "descendant and child combinators",
+			selector: "#sortable-results .rows > li.result-row",
+			expected: "7132606866",
+			found:    true,
+		},
+		{
+			name:     "child combinator requires a direct parent",
+			selector: "#sortable-results > li.result-row",
+			found:    false,
+		},
+		{
+			name:     "alternatives",
+			selector: ".does-not-exist, .result-row",
+			expected: "7132606866",
+			found:    true,
+		},
+		{
+			name:     "invalid selector never matches",
+			selector: "li[data-pid",
+			found:    false,
+			invalid:  true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			compiled, err := compileSelector(test.selector)
+			assert.Equal(t, test.invalid, err != nil)
+
+			node, has := compiled.first(doc)
+			assert.Equal(t, test.found, has)
+			if test.expected != "" {
+				_, pid := findAttr(node.Attr, "data-pid")
+				assert.Equal(t, test.expected, pid)
+			}
+		})
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	t.Run("empty fields use the defaults", func(t *testing.T) {
+		sel := Selectors{Price: ".price"}.withDefaults()
+		assert.Equal(t, ".price", sel.Price)
+		assert.Equal(t, DefaultSelectors.Title, sel.Title)
+	})
+
+	t.Run("should report invalid selectors", func(t *testing.T) {
+		assert.NoError(t, DefaultSelectors.Validate())
+		assert.Error(t, Selectors{Title: "a > > b"}.Validate())
+		assert.Error(t, Selectors{Hood: "span[data-hood"}.Validate())
+		assert.Error(t, Selectors{Hood: `span[title="a]`}.Validate())
+	})
+
+	t.Run("quoted values may hold separators", func(t *testing.T) {
+		doc, err := html.Parse(strings.NewReader(`<p><a title="x">1</a><a title="a, b > c" class="x">2</a></p>`))
+		assert.NoError(t, err)
+
+		compiled, err := compileSelector(`p > a[title="a, b > c"].x`)
+		assert.NoError(t, err)
+		node, has := compiled.first(doc)
+		assert.True(t, has)
+		assert.Equal(t, "2", findText(node))
+
+		compiled, err = compileSelector(`a[title='a, b > c'], a[title=x]`)
+		assert.NoError(t, err)
+		node, has = compiled.first(doc)
+		assert.True(t, has)
+		assert.Equal(t, "1", findText(node))
+	})
+
+	t.Run("invalid overrides are not layout changes", func(t *testing.T) {
+		data, err := ioutil.ReadFile("./test.html")
+		assert.NoError(t, err)
+
+		_, err = (&LegacyParser{}).Parse(bytes.NewReader(data), ParseOptions{
+			Selectors: Selectors{Hood: "span[data-hood"},
+		})
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, ErrLayoutChanged))
+	})
+
+	t.Run("overrides fix renamed markup", func(t *testing.T) {
+		data, err := ioutil.ReadFile("./test.html")
+		assert.NoError(t, err)
+		changed := bytes.ReplaceAll(data, []byte(`class="result-hood"`), []byte(`class="posting-hood"`))
+
+		page, err := (&LegacyParser{}).Parse(bytes.NewReader(changed), ParseOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "", page.Listings[0].Hood)
+
+		page, err = (&LegacyParser{}).Parse(bytes.NewReader(changed), ParseOptions{
+			Selectors: Selectors{Hood: ".posting-hood"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Throgs Neck", page.Listings[0].Hood)
+	})
+}
+
 func TestFindAttr(t *testing.T) {
 	data, err := ioutil.ReadFile("./test.html")
 	assert.NoError(t, err)
@@ -75,7 +211,64 @@ func TestFindAttr(t *testing.T) {
 	}
 }
 
+func TestFindText(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<a class="title">Sofa <b>like new</b>
+		&amp; <span>free &quot;delivery&quot;</span></a>`))
+	assert.NoError(t, err)
+
+	node, _ := findBy(doc, "class", "title")
+	assert.Equal(t, `Sofa like new & free "delivery"`, findText(node))
+	assert.Equal(t, "", findText(nil))
+}
+
+func TestCharset(t *testing.T) {
+	t.Run("should detect the charset", func(t *testing.T) {
+		for _, test := range []struct {
+			name        string
+			contentType string
+			data        string
+			expected    string
+		}{
+			{name: "header", contentType: "text/html; charset=ISO-8859-1", data: `<meta charset="utf-8">`, expected: "iso-8859-1"},
+			{name: "meta charset", contentType: "text/html", data: `<html><head><meta charset="windows-1252">`, expected: "windows-1252"},
+			{name: "meta http-equiv", contentType: "", data: `<meta http-equiv="Content-Type" content="text/html; charset=Shift_JIS">`, expected: "shift_jis"},
+			{name: "xml declaration", contentType: "", data: `<?xml version="1.0" encoding="ISO-8859-1"?><rdf:RDF>`, expected: "iso-8859-1"},
+			{name: "default", contentType: "text/html", data: `<html></html>`, expected: "utf-8"},
+		} {
+			assert.Equal(t, test.expected, detectCharset(test.contentType, []byte(test.data)), test.name)
+		}
+	})
+
+	t.Run("should convert to utf-8", func(t *testing.T) {
+		converted, err := toUTF8([]byte("ch\xeane"), "iso-8859-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "chêne", string(converted))
+
+		converted, err = toUTF8([]byte("\x80200 \x93ok\x94"), "windows-1252")
+		assert.NoError(t, err)
+		assert.Equal(t, "€200 “ok”", string(converted))
+
+		converted, err = toUTF8([]byte("\xef\xbb\xbfok"), "utf-8")
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", string(converted))
+
+		converted, err = toUTF8([]byte("\x8aw\x8fK\x8a\xf7"), "shift_jis")
+		assert.NoError(t, err)
+		assert.Equal(t, "学習机", string(converted))
+
+		converted, err = toUTF8([]byte("\xa5\xbd\xa5\xd5\xa5\xa1"), "euc-jp")
+		assert.NoError(t, err)
+		assert.Equal(t, "ソファ", string(converted))
+
+		_, err = toUTF8([]byte("ok"), "x-unknown")
+		assert.Error(t, err)
+	})
+}
+
 func TestExtractListings(t *testing.T) {
+	sel, err := DefaultSelectors.compile()
+	assert.NoError(t, err)
+
 	t.Run("no cutoff time provided", func(t *testing.T) {
 		data, err := ioutil.ReadFile("./test.html")
 		assert.NoError(t, err)
@@ -88,9 +281,17 @@ func TestExtractListings(t *testing.T) {
 		resultSection, _ := findBy(doc, "id", "sortable-results")
 		// find the resultList, everything in here will go into the listing slice
 		resultList, _ := findBy(resultSection, "class", "rows")
-		listings := extractListings(resultList, nilTime)
+		listings := extractListings(resultList, nilTime, sel)
 
 		assert.Equal(t, 120, len(listings))
+		assert.Equal(t, "Vintage Electric Tomato Strainer Machine", listings[0].Title)
+		assert.Len(t, listings[0].ImageIDs, 12)
+		assert.Equal(t, "newyork", listings[0].Area)
+		assert.Equal(t, "brx", listings[0].SubAreaAbbr)
+		assert.Equal(t, "atq", listings[0].Category)
+		assert.Equal(t, "bronx-vintage-electric-tomato-strainer", listings[0].Slug)
+		assert.Equal(t, listings[0].Link, listings[0].Ref().URL())
+		assert.Equal(t, "00a0a_ggtoxMsCVLk_0CI0t2", listings[0].ImageIDs[0])
 	})
 
 	t.Run("cutoff date provided", func(t *testing.T) {
@@ -110,8 +311,348 @@ func TestExtractListings(t *testing.T) {
 		cutoff, err := time.Parse(layout, "2020-06-08 14:03")
 		assert.NoError(t, err)
 
-		listings := extractListings(resultList, cutoff)
+		listings := extractListings(resultList, cutoff, sel)
 
 		assert.Equal(t, 19, len(listings))
 	})
+
+	t.Run("malformed dates are skipped", func(t *testing.T) {
+		doc, err := html.Parse(strings.NewReader(`<ul class="rows">
+			<li class="result-row" data-pid="1"><p class="result-info">
+				<time class="result-date" datetime="2020-06-08 9:45pm" title="Mon 08 Jun 02:45:12 PM"></time>
+				<a href="https://newyork.craigslist.org/atq/d/broken/1.html" class="result-title hdrlnk">broken</a>
+			</p></li>
+			<li class="result-row" data-pid="2"><p class="result-info">
+				<time class="result-date" datetime="2020-06-08 14:45" title="Mon 08 Jun 02:45:12 PM"></time>
+				<a href="https://newyork.craigslist.org/atq/d/fine/2.html" class="result-title hdrlnk">fine</a>
+			</p></li>
+		</ul>`))
+		assert.NoError(t, err)
+		resultList, _ := findBy(doc, "class", "rows")
+
+		cutoff := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		listings := extractListings(resultList, cutoff, sel)
+
+		assert.Len(t, listings, 1)
+		assert.Equal(t, "fine", listings[0].Title)
+	})
+}
+
+func TestNormalizeHood(t *testing.T) {
+	for _, test := range []struct {
+		given    string
+		expected string
+	}{
+		{given: " (Throgs Neck)", expected: "Throgs Neck"},
+		{given: " (QUEENS,)", expected: "QUEENS"},
+		{given: "(Upper  West\n Side)", expected: "Upper West Side"},
+		{given: "Brooklyn", expected: "Brooklyn"},
+		{given: "", expected: ""},
+	} {
+		assert.Equal(t, test.expected, normalizeHood(test.given))
+	}
+}
+
+func TestParseListingURL(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		given    string
+		expected ListingRef
+		err      bool
+	}{
+		{
+			name:  "with subarea",
+			given: "https://newyork.craigslist.org/brx/atq/d/bronx-vintage-electric-tomato-strainer/7132606866.html",
+			expected: ListingRef{
+				Area:     "newyork",
+				SubArea:  "brx",
+				Category: "atq",
+				Slug:     "bronx-vintage-electric-tomato-strainer",
+				PostID:   "7132606866",
+			},
+		},
+		{
+			name:  "without subarea",
+			given: "https://boise.craigslist.org/atq/d/boise-oak-dresser/7130000000.html",
+			expected: ListingRef{
+				Area:     "boise",
+				Category: "atq",
+				Slug:     "boise-oak-dresser",
+				PostID:   "7130000000",
+			},
+		},
+		{
+			name:  "older link without slug",
+			given: "http://sfbay.craigslist.org/eby/atq/7130000001.html",
+			expected: ListingRef{
+				Area:     "sfbay",
+				SubArea:  "eby",
+				Category: "atq",
+				PostID:   "7130000001",
+			},
+		},
+		{
+			name:  "not craigslist",
+			given: "https://newyork.example.com/brx/atq/d/slug/7132606866.html",
+			err:   true,
+		},
+		{
+			name:  "not a posting",
+			given: "https://newyork.craigslist.org/search/atq?query=lamp",
+			err:   true,
+		},
+		{
+			name:  "invalid post id",
+			given: "https://newyork.craigslist.org/atq/d/slug/abc.html",
+			err:   true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ref, err := ParseListingURL(test.given)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ref)
+			assert.Equal(t, strings.Replace(test.given, "http://", "https://", 1), ref.URL())
+		})
+	}
+}
+
+func TestDetectParser(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		contentType string
+		file        string
+		expected    Parser
+	}{
+		{
+			name:        "json content type",
+			contentType: "application/json; charset=utf-8",
+			file:        "./test_search.json",
+			expected:    &JSONParser{},
+		},
+		{
+			name:        "rss content type",
+			contentType: "application/rss+xml",
+			file:        "./test_search.rss",
+			expected:    &RSSParser{},
+		},
+		{
+			name:        "json sniffed from markup",
+			contentType: "text/plain; charset=utf-8",
+			file:        "./test_search.json",
+			expected:    &JSONParser{},
+		},
+		{
+			name:        "rss sniffed from markup",
+			contentType: "text/plain; charset=utf-8",
+			file:        "./test_search.rss",
+			expected:    &RSSParser{},
+		},
+		{
+			name:        "static layout",
+			contentType: "text/html; charset=utf-8",
+			file:        "./test_static.html",
+			expected:    &StaticParser{},
+		},
+		{
+			name:        "legacy layout",
+			contentType: "text/html; charset=utf-8",
+			file:        "./test.html",
+			expected:    &LegacyParser{},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := ioutil.ReadFile(test.file)
+			assert.NoError(t, err)
+
+			assert.IsType(t, test.expected, DetectParser(test.contentType, data))
+		})
+	}
+}
+
+func TestLegacyParser(t *testing.T) {
+	data, err := ioutil.ReadFile("./test.html")
+	assert.NoError(t, err)
+
+	page, err := (&LegacyParser{}).Parse(bytes.NewReader(data), ParseOptions{})
+	assert.NoError(t, err)
+
+	assert.Len(t, page.Listings, 120)
+	assert.Equal(t, 3000, page.TotalCount)
+	assert.Equal(t, 1, page.RangeFrom)
+	assert.Equal(t, 120, page.RangeTo)
+	assert.Equal(t, "/search/atq?s=120", page.NextPage)
+}
+
+func TestLegacyParserLayoutChanged(t *testing.T) {
+	data, err := ioutil.ReadFile("./test.html")
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		name     string
+		old      string
+		new      string
+		expected []string
+	}{
+		{
+			name:     "results container missing",
+			old:      `id="sortable-results"`,
+			new:      `id="results"`,
+			expected: []string{AnchorResults, AnchorRows},
+		},
+		{
+			name:     "rows missing",
+			old:      `class="rows"`,
+			new:      `class="results"`,
+			expected: []string{AnchorRows},
+		},
+		{
+			name:     "totalcount missing",
+			old:      `class="totalcount"`,
+			new:      `class="count"`,
+			expected: []string{AnchorTotalCount},
+		},
+		{
+			name:     "result-date missing",
+			old:      `class="result-date"`,
+			new:      `class="date"`,
+			expected: []string{AnchorResultDate},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			changed := bytes.ReplaceAll(data, []byte(test.old), []byte(test.new))
+
+			page, err := (&LegacyParser{}).Parse(bytes.NewReader(changed), ParseOptions{})
+			assert.Nil(t, page)
+			assert.True(t, errors.Is(err, ErrLayoutChanged))
+
+			var layoutErr *LayoutError
+			assert.True(t, errors.As(err, &layoutErr))
+			assert.Equal(t, test.expected, layoutErr.Missing)
+		})
+	}
+
+	t.Run("a single malformed date is not a layout change", func(t *testing.T) {
+		page, err := (&LegacyParser{}).Parse(strings.NewReader(`<div id="sortable-results">
+			<span class="totalcount">2</span>
+			<ul class="rows">
+				<li class="result-row" data-pid="1"><p class="result-info">
+					<time class="result-date" datetime="2020-06-08 9:45pm" title="Mon 08 Jun 02:45:12 PM"></time>
+					<a href="https://newyork.craigslist.org/atq/d/broken/1.html" class="result-title hdrlnk">broken</a>
+				</p></li>
+				<li class="result-row" data-pid="2"><p class="result-info">
+					<time class="result-date" datetime="2020-06-08 14:45" title="Mon 08 Jun 02:45:12 PM"></time>
+					<a href="https://newyork.craigslist.org/atq/d/fine/2.html" class="result-title hdrlnk">fine</a>
+				</p></li>
+			</ul>
+		</div>`), ParseOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, page.Listings, 1)
+		assert.Equal(t, "fine", page.Listings[0].Title)
+	})
+}
+
+func TestHousing(t *testing.T) {
+	t.Run("should parse housing details", func(t *testing.T) {
+		for _, test := range []struct {
+			given        string
+			bedrooms     int
+			squareFeet   int
+			squareMeters int
+		}{
+			{given: "2br - 900ft2 -", bedrooms: 2, squareFeet: 900, squareMeters: 84},
+			{given: "450ft2 -", squareFeet: 450, squareMeters: 42},
+			{given: "3br -", bedrooms: 3},
+			{given: "1br - 80m2 -", bedrooms: 1, squareFeet: 861, squareMeters: 80},
+			{given: ""},
+		} {
+			bedrooms, squareFeet, squareMeters := parseHousing(test.given)
+			assert.Equal(t, test.bedrooms, bedrooms, test.given)
+			assert.Equal(t, test.squareFeet, squareFeet, test.given)
+			assert.Equal(t, test.squareMeters, squareMeters, test.given)
+		}
+	})
+
+	t.Run("should extract housing details from result rows", func(t *testing.T) {
+		data, err := ioutil.ReadFile("./test_housing.html")
+		assert.NoError(t, err)
+
+		page, err := (&LegacyParser{}).Parse(bytes.NewReader(data), ParseOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, page.Listings, 4)
+		assert.Equal(t, 4, page.TotalCount)
+
+		for i, expected := range []struct {
+			bedrooms     int
+			squareFeet   int
+			squareMeters int
+		}{
+			{bedrooms: 2, squareFeet: 900, squareMeters: 84},
+			{squareFeet: 450, squareMeters: 42},
+			{bedrooms: 3},
+			{bedrooms: 1, squareFeet: 861, squareMeters: 80},
+		} {
+			assert.Equal(t, expected.bedrooms, page.Listings[i].Bedrooms)
+			assert.Equal(t, expected.squareFeet, page.Listings[i].SquareFeet)
+			assert.Equal(t, expected.squareMeters, page.Listings[i].SquareMeters)
+		}
+
+		assert.Equal(t, "$2,100", page.Listings[0].Price)
+		assert.Equal(t, "Astoria", page.Listings[0].Hood)
+		assert.Equal(t, "apa", page.Listings[0].Category)
+	})
+
+	t.Run("rows outside housing have no details", func(t *testing.T) {
+		data, err := ioutil.ReadFile("./test.html")
+		assert.NoError(t, err)
+
+		page, err := (&LegacyParser{}).Parse(bytes.NewReader(data), ParseOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, page.Listings[0].Bedrooms)
+		assert.Equal(t, 0, page.Listings[0].SquareFeet)
+	})
+}
+
+func TestStaticParser(t *testing.T) {
+	data, err := ioutil.ReadFile("./test_static.html")
+	assert.NoError(t, err)
+
+	page, err := (&StaticParser{}).Parse(bytes.NewReader(data), ParseOptions{})
+	assert.NoError(t, err)
+
+	assert.Len(t, page.Listings, 3)
+	assert.Equal(t, 3, page.TotalCount)
+
+	assert.Equal(t, Listing{
+		DataPID:     "7132606866",
+		Title:       "Vintage Electric Tomato Strainer Machine",
+		Link:        "https://newyork.craigslist.org/brx/atq/d/bronx-vintage-electric-tomato-strainer/7132606866.html",
+		Price:       "$250",
+		Hood:        "Throgs Neck",
+		Area:        "newyork",
+		SubAreaAbbr: "brx",
+		Category:    "atq",
+		Slug:        "bronx-vintage-electric-tomato-strainer",
+	}, page.Listings[0])
+
+	// missing details should not leak in from the next row
+	assert.Equal(t, "", page.Listings[1].Hood)
+	assert.Equal(t, "", page.Listings[2].Price)
+	assert.Equal(t, "Brooklyn", page.Listings[2].Hood)
+
+	// without dates a cutoff cannot be applied
+	_, err = (&StaticParser{}).Parse(bytes.NewReader(data), ParseOptions{Cutoff: time.Now()})
+	assert.Error(t, err)
+
+	// extra classes on rows and fields should not hide them
+	extra := bytes.ReplaceAll(data, []byte(`class="cl-static-search-result"`), []byte(`class="cl-static-search-result cl-search-result"`))
+	extra = bytes.ReplaceAll(extra, []byte(`class="price"`), []byte(`class="price priceinfo"`))
+	page, err = (&StaticParser{}).Parse(bytes.NewReader(extra), ParseOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Listings, 3)
+	assert.Equal(t, "$250", page.Listings[0].Price)
 }
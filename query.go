@@ -0,0 +1,216 @@
+package gocraigslist
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Query builds a search term in craigslist's query syntax: required words
+// and "exact phrases", -excluded words and (this|that) groups of which at
+// least one has to match. The same query can be run against the returned
+// listings with Match, since craigslist matches loosely and also searches
+// text that is not returned, like the posting body.
+//
+//	q := NewQuery("xbox").Require("series x").Exclude("broken").AnyOf("controller", "headset")
+//	url := client.FormatURL(q.String(), q.Options(Options{}))
+type Query struct {
+	words     []string
+	phrases   []string
+	excluded  []string
+	groups    [][]string
+	titleOnly bool
+}
+
+// NewQuery starts a query requiring every word given. Query syntax in the
+// words is dropped, use Exclude and AnyOf for those.
+func NewQuery(words ...string) *Query {
+	q := &Query{}
+	for _, w := range words {
+		for _, field := range strings.Fields(w) {
+			q.words = append(q.words, strings.Fields(cleanQueryTerm(field))...)
+		}
+	}
+
+	return q
+}
+
+// Require adds a phrase that has to appear exactly, word for word.
+func (q *Query) Require(phrase string) *Query {
+	if phrase = cleanQueryTerm(phrase); phrase != "" {
+		q.phrases = append(q.phrases, phrase)
+	}
+	return q
+}
+
+// Exclude drops results containing any of the words or phrases given.
+func (q *Query) Exclude(terms ...string) *Query {
+	for _, term := range terms {
+		if term = cleanQueryTerm(term); term != "" {
+			q.excluded = append(q.excluded, term)
+		}
+	}
+	return q
+}
+
+// AnyOf adds a group of words or phrases of which at least one has to appear.
+func (q *Query) AnyOf(terms ...string) *Query {
+	group := []string{}
+	for _, term := range terms {
+		if term = cleanQueryTerm(term); term != "" {
+			group = append(group, term)
+		}
+	}
+
+	if len(group) > 0 {
+		q.groups = append(q.groups, group)
+	}
+	return q
+}
+
+// TitleOnly limits the search to listing titles, see Options.SrchType.
+func (q *Query) TitleOnly() *Query {
+	q.titleOnly = true
+	return q
+}
+
+// String renders the query in craigslist's syntax, ready for FormatURL.
+func (q *Query) String() string {
+	pieces := append([]string{}, q.words...)
+	for _, phrase := range q.phrases {
+		pieces = append(pieces, quoteQueryTerm(phrase))
+	}
+
+	for _, group := range q.groups {
+		alternatives := []string{}
+		for _, term := range group {
+			alternatives = append(alternatives, quoteQueryTerm(term))
+		}
+		if len(alternatives) == 1 {
+			pieces = append(pieces, alternatives[0])
+			continue
+		}
+		pieces = append(pieces, "("+strings.Join(alternatives, "|")+")")
+	}
+
+	for _, term := range q.excluded {
+		pieces = append(pieces, "-"+quoteQueryTerm(term))
+	}
+
+	return strings.Join(pieces, " ")
+}
+
+// Options returns options searching titles only when the query is
+// TitleOnly, options already searching titles only are left as is.
+func (q *Query) Options(options Options) Options {
+	if q.titleOnly {
+		options.SrchType = true
+	}
+	return options
+}
+
+// Match reports whether the title, and unless the query is TitleOnly the
+// description, of a listing satisfy the query. Words are matched whole and
+// without regard to case.
+func (q *Query) Match(l Listing) bool {
+	text := l.Title
+	if !q.titleOnly {
+		text += " " + l.Description
+	}
+	tokens := queryTokens(text)
+
+	for _, w := range q.words {
+		if !containsTokens(tokens, queryTokens(w)) {
+			return false
+		}
+	}
+
+	for _, phrase := range q.phrases {
+		if !containsTokens(tokens, queryTokens(phrase)) {
+			return false
+		}
+	}
+
+	for _, term := range q.excluded {
+		if containsTokens(tokens, queryTokens(term)) {
+			return false
+		}
+	}
+
+	for _, group := range q.groups {
+		matched := false
+		for _, term := range group {
+			if containsTokens(tokens, queryTokens(term)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Filter keeps the listings that Match the query.
+func (q *Query) Filter(listings []Listing) []Listing {
+	matched := []Listing{}
+	for _, l := range listings {
+		if q.Match(l) {
+			matched = append(matched, l)
+		}
+	}
+
+	return matched
+}
+
+// cleanQueryTerm strips the characters that have a meaning in the query
+// syntax from a term.
+func cleanQueryTerm(term string) string {
+	term = strings.Map(func(r rune) rune {
+		switch r {
+		case '"', '(', ')', '|':
+			return ' '
+		}
+		return r
+	}, term)
+
+	return strings.Join(strings.Fields(strings.TrimLeft(strings.TrimSpace(term), "-")), " ")
+}
+
+// quoteQueryTerm quotes terms of more than one word.
+func quoteQueryTerm(term string) string {
+	if strings.Contains(term, " ") {
+		return `"` + term + `"`
+	}
+	return term
+}
+
+// queryTokens splits text into lower case words.
+func queryTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsTokens reports whether needle appears in tokens as a contiguous run.
+func containsTokens(tokens []string, needle []string) bool {
+	if len(needle) == 0 {
+		return true
+	}
+
+	for i := 0; i+len(needle) <= len(tokens); i++ {
+		matched := true
+		for j, n := range needle {
+			if tokens[i+j] != n {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
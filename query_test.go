@@ -0,0 +1,76 @@
+package gocraigslist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery(t *testing.T) {
+	t.Run("should render craigslist query syntax", func(t *testing.T) {
+		for _, test := range []struct {
+			given    *Query
+			expected string
+		}{
+			{given: NewQuery("xbox"), expected: "xbox"},
+			{given: NewQuery("xbox one", "s"), expected: "xbox one s"},
+			{given: NewQuery().Require("series x"), expected: `"series x"`},
+			{given: NewQuery("xbox").Exclude("broken", "for parts"), expected: `xbox -broken -"for parts"`},
+			{given: NewQuery().AnyOf("xbox", "playstation 5"), expected: `(xbox|"playstation 5")`},
+			{given: NewQuery().AnyOf("xbox"), expected: "xbox"},
+			{given: NewQuery("-broken", "a|b"), expected: "broken a b"},
+			{given: NewQuery(`xbox -"for parts" (one)`), expected: "xbox for parts one"},
+			{
+				given:    NewQuery("console").Require("like new").AnyOf("xbox", "playstation").Exclude("-broken").TitleOnly(),
+				expected: `console "like new" (xbox|playstation) -broken`,
+			},
+			{given: NewQuery().Require(`"quoted" (stuff)`).AnyOf("", "|"), expected: `"quoted stuff"`},
+		} {
+			assert.Equal(t, test.expected, test.given.String())
+		}
+	})
+
+	t.Run("should be usable with FormatURL", func(t *testing.T) {
+		client := NewClient("newyork")
+		q := NewQuery("xbox").Exclude("broken").TitleOnly()
+
+		url := client.FormatURL(q.String(), q.Options(Options{HasPic: true}))
+		assert.Equal(t, "https://newyork.craigslist.org/search/sss?query=xbox+-broken&sort=rel&srchType=T&hasPic=1", url)
+	})
+
+	t.Run("should match listings the way craigslist should have", func(t *testing.T) {
+		q := NewQuery("xbox").Require("series x").Exclude("broken").AnyOf("controller", "extra games")
+
+		for _, test := range []struct {
+			given    Listing
+			expected bool
+		}{
+			{given: Listing{Title: "Xbox Series X with controller"}, expected: true},
+			{given: Listing{Title: "XBOX series-x", Description: "comes with extra games"}, expected: true},
+			{given: Listing{Title: "Xbox Series S with controller"}, expected: false},
+			{given: Listing{Title: "Xbox Series X with controller", Description: "disc drive is broken"}, expected: false},
+			{given: Listing{Title: "Xbox Series X"}, expected: false},
+			{given: Listing{Title: "Xboxes series x controllers"}, expected: false},
+		} {
+			assert.Equal(t, test.expected, q.Match(test.given), test.given.Title)
+		}
+	})
+
+	t.Run("should only match titles when TitleOnly", func(t *testing.T) {
+		q := NewQuery("xbox").TitleOnly()
+
+		assert.True(t, q.Match(Listing{Title: "xbox"}))
+		assert.False(t, q.Match(Listing{Title: "console", Description: "an xbox"}))
+	})
+
+	t.Run("should filter listings", func(t *testing.T) {
+		listings := []Listing{
+			{DataPID: "1", Title: "xbox one"},
+			{DataPID: "2", Title: "broken xbox"},
+			{DataPID: "3", Title: "playstation"},
+		}
+
+		filtered := NewQuery("xbox").Exclude("broken").Filter(listings)
+		assert.Equal(t, []Listing{listings[0]}, filtered)
+	})
+}
@@ -0,0 +1,142 @@
+package gocraigslist
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RepostChain follows an item craigslist reports as reposted through every
+// posting of it that has been seen. OriginalPID is the first posting of the
+// item, which is only part of Posts when it was seen itself.
+type RepostChain struct {
+	OriginalPID string
+	Posts       []Listing // oldest first
+}
+
+// PricePoint is the price of an item as of one of its postings.
+type PricePoint struct {
+	PID   string
+	Date  time.Time
+	Price int
+}
+
+// BuildRepostChains groups listings by the posting they are a repost of,
+// using DataRepostOf. Listings that were never reposted are left out and
+// duplicate sightings of the same posting are only counted once.
+// Chains are ordered by OriginalPID.
+func BuildRepostChains(listings []Listing) []RepostChain {
+	seen := map[string]Listing{}
+	for _, l := range listings {
+		if l.DataPID != "" {
+			seen[l.DataPID] = l
+		}
+	}
+
+	chains := map[string]*RepostChain{}
+	for _, l := range seen {
+		if l.DataRepostOf == "" {
+			continue
+		}
+
+		chain, has := chains[l.DataRepostOf]
+		if !has {
+			chain = &RepostChain{OriginalPID: l.DataRepostOf}
+			if original, has := seen[l.DataRepostOf]; has {
+				chain.Posts = append(chain.Posts, original)
+			}
+			chains[l.DataRepostOf] = chain
+		}
+		chain.Posts = append(chain.Posts, l)
+	}
+
+	result := []RepostChain{}
+	for _, chain := range chains {
+		sort.Slice(chain.Posts, func(i, j int) bool {
+			a, b := listingTime(chain.Posts[i]), listingTime(chain.Posts[j])
+			if !a.Equal(b) {
+				return a.Before(b)
+			}
+			return comparePIDs(chain.Posts[i].DataPID, chain.Posts[j].DataPID) < 0
+		})
+		result = append(result, *chain)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return comparePIDs(result[i].OriginalPID, result[j].OriginalPID) < 0
+	})
+
+	return result
+}
+
+// FirstSeen is the date of the oldest posting in the chain.
+func (r RepostChain) FirstSeen() time.Time {
+	if len(r.Posts) == 0 {
+		return nilTime
+	}
+	return listingTime(r.Posts[0])
+}
+
+// LastSeen is the date of the newest posting in the chain.
+func (r RepostChain) LastSeen() time.Time {
+	if len(r.Posts) == 0 {
+		return nilTime
+	}
+	return listingTime(r.Posts[len(r.Posts)-1])
+}
+
+// Age is how long the item has been on the market according to the postings
+// seen, from the oldest to the newest.
+func (r RepostChain) Age() time.Duration {
+	return r.LastSeen().Sub(r.FirstSeen())
+}
+
+// PriceHistory lists the price of every posting in the chain that has one,
+// oldest first.
+func (r RepostChain) PriceHistory() []PricePoint {
+	history := []PricePoint{}
+	for _, post := range r.Posts {
+		price, ok := parsePrice(post.Price)
+		if !ok {
+			continue
+		}
+		history = append(history, PricePoint{PID: post.DataPID, Date: listingTime(post), Price: price})
+	}
+
+	return history
+}
+
+// listingTime parses the Date of a listing, which carries no timezone and is
+// only meant to be compared with the dates of listings from the same area.
+func listingTime(l Listing) time.Time {
+	t, err := time.Parse("2006-01-02 15:04:05", l.Date)
+	if err != nil {
+		return nilTime
+	}
+	return t
+}
+
+// parsePrice converts a price like "$1,250" to 1250.
+func parsePrice(price string) (int, bool) {
+	price = strings.TrimSpace(price)
+	price = strings.TrimLeft(price, "$€£¥")
+	price = strings.Replace(price, ",", "", -1)
+	if price == "" {
+		return 0, false
+	}
+
+	value, err := strconv.Atoi(price)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// comparePIDs orders posting ids numerically, ids are always increasing.
+func comparePIDs(a string, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,53 @@
+package gocraigslist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildRepostChains(t *testing.T) {
+	listings := []Listing{
+		{DataPID: "7138122864", DataRepostOf: "7106703240", Date: "2020-06-08 14:41:19", Price: "$400"},
+		{DataPID: "7132606866", Date: "2020-06-08 14:45:12", Price: "$250"},
+		{DataPID: "7120000000", DataRepostOf: "7106703240", Date: "2020-05-28 09:00:00", Price: "$450"},
+		{DataPID: "7106703240", Date: "2020-05-10 12:30:00", Price: "$1,500"},
+		{DataPID: "7128655269", DataRepostOf: "6958205465", Date: "2020-06-08 14:33:20"},
+		// seen twice across pages
+		{DataPID: "7138122864", DataRepostOf: "7106703240", Date: "2020-06-08 14:41:19", Price: "$400"},
+	}
+
+	chains := BuildRepostChains(listings)
+	assert.Len(t, chains, 2)
+
+	t.Run("should order chains and posts", func(t *testing.T) {
+		assert.Equal(t, "6958205465", chains[0].OriginalPID)
+		assert.Len(t, chains[0].Posts, 1)
+
+		chain := chains[1]
+		assert.Equal(t, "7106703240", chain.OriginalPID)
+		assert.Len(t, chain.Posts, 3)
+		assert.Equal(t, "7106703240", chain.Posts[0].DataPID)
+		assert.Equal(t, "7120000000", chain.Posts[1].DataPID)
+		assert.Equal(t, "7138122864", chain.Posts[2].DataPID)
+	})
+
+	t.Run("should report time on the market", func(t *testing.T) {
+		chain := chains[1]
+		assert.Equal(t, time.Date(2020, 5, 10, 12, 30, 0, 0, time.UTC), chain.FirstSeen())
+		assert.Equal(t, time.Date(2020, 6, 8, 14, 41, 19, 0, time.UTC), chain.LastSeen())
+		assert.Equal(t, chain.LastSeen().Sub(chain.FirstSeen()), chain.Age())
+
+		assert.Equal(t, time.Duration(0), chains[0].Age())
+	})
+
+	t.Run("should report the price history", func(t *testing.T) {
+		history := chains[1].PriceHistory()
+		assert.Equal(t, []int{1500, 450, 400}, []int{history[0].Price, history[1].Price, history[2].Price})
+		assert.Equal(t, "7120000000", history[1].PID)
+
+		// postings without a price are skipped
+		assert.Empty(t, chains[0].PriceHistory())
+	})
+}
@@ -0,0 +1,124 @@
+package gocraigslist
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// rssTitlePrice matches the price craigslist appends to the end of each item title.
+var rssTitlePrice = regexp.MustCompile(`\s+(\$[0-9,.]+)$`)
+
+// rssImageSize matches the size suffix on enclosure image file names.
+var rssImageSize = regexp.MustCompile(`_\d+x\d+$`)
+
+// rssFeed is craigslist's rdf flavored rss 1.0 feed. Items carry their
+// date in the dublin core namespace and images as enclosures.
+type rssFeed struct {
+	Items []rssItem `xml:"http://purl.org/rss/1.0/ item"`
+}
+
+type rssItem struct {
+	About       string         `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string         `xml:"http://purl.org/rss/1.0/ title"`
+	Link        string         `xml:"http://purl.org/rss/1.0/ link"`
+	Description string         `xml:"http://purl.org/rss/1.0/ description"`
+	Date        string         `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Source      string         `xml:"http://purl.org/dc/elements/1.1/ source"`
+	Enclosures  []rssEnclosure `xml:"http://purl.oclc.org/net/rss_2.0/enc# enclosure"`
+}
+
+type rssEnclosure struct {
+	Resource string `xml:"resource,attr"`
+	Type     string `xml:"type,attr"`
+}
+
+// rssSearchURL converts a search url built by FormatURL into its rss variant.
+func rssSearchURL(searchURL string) (string, error) {
+	u, err := url.Parse(searchURL)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set("format", "rss")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// RSSParser parses the rss variant of the search page. The feed has no total
+// count so the count of a Page is the number of items in it.
+type RSSParser struct{}
+
+// Parse implements Parser.
+func (p *RSSParser) Parse(data io.Reader, options ParseOptions) (*Page, error) {
+	feed := rssFeed{}
+	decoder := xml.NewDecoder(data)
+	decoder.CharsetReader = charsetReader
+	err := decoder.Decode(&feed)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode data: %v", err)
+	}
+
+	listings := []Listing{}
+	for _, item := range feed.Items {
+		posted, err := time.Parse(time.RFC3339, strings.TrimSpace(item.Date))
+		if err != nil {
+			return &Page{Listings: listings, TotalCount: len(listings)}, fmt.Errorf("unable to parse date: %v", err)
+		}
+
+		if options.Cutoff != nilTime && posted.Before(options.Cutoff) {
+			break
+		}
+
+		title := html.UnescapeString(strings.TrimSpace(item.Title))
+		var price string
+		if match := rssTitlePrice.FindStringSubmatch(title); match != nil {
+			price = match[1]
+			title = strings.TrimSpace(strings.TrimSuffix(title, match[0]))
+		}
+
+		link := strings.TrimSpace(item.Link)
+
+		imageIDs := []string{}
+		for _, enclosure := range item.Enclosures {
+			if !strings.HasPrefix(enclosure.Type, "image/") {
+				continue
+			}
+			id := strings.TrimSuffix(path.Base(enclosure.Resource), path.Ext(enclosure.Resource))
+			imageIDs = append(imageIDs, rssImageSize.ReplaceAllString(id, ""))
+		}
+
+		newListing := Listing{
+			Date:        posted.Format("2006-01-02 15:04:05"),
+			Title:       title,
+			Link:        link,
+			Price:       price,
+			Description: html.UnescapeString(strings.TrimSpace(item.Description)),
+			ImageIDs:    imageIDs,
+		}
+		finishListing(&newListing)
+
+		// links without an area take it from the posting's source instead
+		for _, source := range []string{item.Source, item.About} {
+			if newListing.Area != "" {
+				break
+			}
+			if ref, err := parseListingURL(strings.TrimSpace(source), ""); err == nil {
+				newListing.Area = ref.Area
+				newListing.SubAreaAbbr = ref.SubArea
+			}
+		}
+
+		listings = append(listings, newListing)
+	}
+
+	return &Page{Listings: listings, TotalCount: len(listings)}, nil
+}
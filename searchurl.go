@@ -0,0 +1,161 @@
+package gocraigslist
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ParseSearchURL is the inverse of FormatURL, it turns a craigslist search
+// url, such as one copied from a browser, back into its term and Options.
+// Location and Category are always set on the returned Options, except for
+// the default sss category which maps back to an empty Category.
+func (c *Client) ParseSearchURL(rawURL string) (string, Options, error) {
+	options := Options{}
+
+	hostname, err := hostnameFromURL(rawURL, c.baseDomain())
+	if err != nil {
+		return "", options, err
+	}
+	options.Location = hostname
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", options, fmt.Errorf("unable to parse url: %v", err)
+	}
+
+	// craigslist also links searches as /d/<category name>/search/<category>
+	index := strings.Index(u.Path, defPath)
+	if index < 0 {
+		return "", options, fmt.Errorf("not a search url: %s", rawURL)
+	}
+	pieces := strings.Split(strings.Trim(u.Path[index+len(defPath):], "/"), "/")
+	if len(pieces) == 2 && pieces[0] != "" {
+		options.SubArea = pieces[0]
+		pieces = pieces[1:]
+	}
+	if len(pieces) != 1 || pieces[0] == "" {
+		return "", options, fmt.Errorf("unexpected search path: %s", u.Path)
+	}
+
+	// owner and dealer variants are read back as PostedBy on their category
+	category := pieces[0]
+	switch category {
+	case defCategory:
+	case defCategoryOwner:
+		options.PostedBy = string(PostedByOwner)
+	case defCategoryDealer:
+		options.PostedBy = string(PostedByDealer)
+	default:
+		options.Category = category
+		if known, has := categoryByCode(c.categories(), category); has && category != known.Code {
+			options.Category = known.Code
+			options.PostedBy = string(PostedByOwner)
+			if category == known.Dealer {
+				options.PostedBy = string(PostedByDealer)
+			}
+		}
+	}
+
+	q := u.Query()
+	options.SrchType = q.Get("srchType") == "T"
+	options.HasPic = q.Get("hasPic") == "1"
+	options.PostedToday = q.Get("postedToday") == "1"
+	options.BundleDuplicates = q.Get("bundleDuplicates") == "1"
+	options.CryptoCurrencyOK = q.Get("crypto_currency_ok") == "1"
+	options.DeliveryAvailable = q.Get("delivery_available") == "1"
+	options.IncludeNearby = q.Get("searchNearby") == "1" || q.Get("searchNearby") == "2"
+	for _, value := range q["nearbyArea"] {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return "", options, fmt.Errorf("invalid nearby area: %s", value)
+		}
+		options.NearbyAreas = append(options.NearbyAreas, id)
+	}
+	if q.Get("searchNearby") == "2" && len(options.NearbyAreas) == 0 {
+		return "", options, fmt.Errorf("searchNearby=2 requires a nearbyArea")
+	}
+	options.MinPrice = q.Get("min_price")
+	options.MaxPrice = q.Get("max_price")
+	options.SearchDistance = q.Get("search_distance")
+	options.Postal = q.Get("postal")
+	options.Latitude = q.Get("lat")
+	options.Longitude = q.Get("lon")
+
+	auto, err := parseAutoOptions(q)
+	if err != nil {
+		return "", options, err
+	}
+	options.Auto = auto
+
+	housing, err := parseHousingOptions(q)
+	if err != nil {
+		return "", options, err
+	}
+	options.Housing = housing
+
+	jobs, err := parseJobOptions(q)
+	if err != nil {
+		return "", options, err
+	}
+	options.Jobs = jobs
+
+	// relevance is the default order, leave it unset like FormatURL expects
+	if code := q.Get("sort"); code != "" && code != SortRelevance.Code() {
+		order, err := ParseSortOrder(code)
+		if err != nil {
+			return "", options, err
+		}
+		options.Sort = order
+	}
+
+	for _, code := range q["condition"] {
+		name, has := lookupName(conditionMap, code)
+		if !has {
+			return "", options, fmt.Errorf("unknown condition code: %s", code)
+		}
+		options.Condition = append(options.Condition, name)
+	}
+
+	for _, code := range q["language"] {
+		name, has := lookupName(languageMap, code)
+		if !has {
+			return "", options, fmt.Errorf("unknown language code: %s", code)
+		}
+		options.Language = append(options.Language, name)
+	}
+
+	return q.Get("query"), options, nil
+}
+
+// searchSubArea returns the subarea a search url is scoped to, as in
+// /search/brk/sss, or an empty string.
+func searchSubArea(searchURL string) string {
+	u, err := url.Parse(searchURL)
+	if err != nil {
+		return ""
+	}
+
+	index := strings.Index(u.Path, defPath)
+	if index < 0 {
+		return ""
+	}
+
+	pieces := strings.Split(strings.Trim(u.Path[index+len(defPath):], "/"), "/")
+	if len(pieces) != 2 {
+		return ""
+	}
+	return pieces[0]
+}
+
+// lookupName finds the option name mapped to a craigslist code.
+func lookupName(codes map[string]string, code string) (string, bool) {
+	for name, c := range codes {
+		if c == code {
+			return name, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,487 @@
+package gocraigslist
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Selectors are the css selectors used to extract listings from the legacy
+// html layout. Any field left empty falls back to its DefaultSelectors value,
+// so a markup change can be fixed by overriding a single selector.
+//
+// Supported syntax is a subset of css: type (li), id (#id), class (.class)
+// and attribute ([attr], [attr=value], [attr="quoted value"]) selectors,
+// compounds of those (a.result-title.hdrlnk), descendant and child (>)
+// combinators, and comma separated alternatives.
+type Selectors struct {
+	Results    string // container of the results
+	Rows       string // list within Results, each element child is a row
+	Info       string // element of a row holding its details, rows without it are skipped
+	Date       string // within Info, needs the datetime and title attributes
+	Title      string // within Info, needs the href attribute
+	Price      string // within Info
+	Hood       string // within Info
+	Housing    string // within Info, the "2br - 900ft2" details of housing searches
+	Images     string // within a row, needs the data-ids attribute
+	TotalCount string
+	RangeFrom  string
+	RangeTo    string
+	NextPage   string // needs the href attribute
+	NearbyArea string // each match is one nearby area, holding an input with the area id as value
+}
+
+// DefaultSelectors match the legacy craigslist search page.
+var DefaultSelectors = Selectors{
+	Results:    "#sortable-results",
+	Rows:       ".rows",
+	Info:       ".result-info",
+	Date:       ".result-date",
+	Title:      ".result-title.hdrlnk",
+	Price:      ".result-price",
+	Hood:       ".result-hood",
+	Housing:    ".housing",
+	Images:     ".result-image.gallery",
+	TotalCount: ".totalcount",
+	RangeFrom:  ".rangeFrom",
+	RangeTo:    ".rangeTo",
+	NextPage:   "a.button.next",
+	NearbyArea: "label.nearby",
+}
+
+// withDefaults fills every empty field of s from DefaultSelectors.
+func (s Selectors) withDefaults() Selectors {
+	fill := func(v *string, def string) {
+		if strings.TrimSpace(*v) == "" {
+			*v = def
+		}
+	}
+
+	fill(&s.Results, DefaultSelectors.Results)
+	fill(&s.Rows, DefaultSelectors.Rows)
+	fill(&s.Info, DefaultSelectors.Info)
+	fill(&s.Date, DefaultSelectors.Date)
+	fill(&s.Title, DefaultSelectors.Title)
+	fill(&s.Price, DefaultSelectors.Price)
+	fill(&s.Hood, DefaultSelectors.Hood)
+	fill(&s.Housing, DefaultSelectors.Housing)
+	fill(&s.Images, DefaultSelectors.Images)
+	fill(&s.TotalCount, DefaultSelectors.TotalCount)
+	fill(&s.RangeFrom, DefaultSelectors.RangeFrom)
+	fill(&s.RangeTo, DefaultSelectors.RangeTo)
+	fill(&s.NextPage, DefaultSelectors.NextPage)
+	fill(&s.NearbyArea, DefaultSelectors.NearbyArea)
+
+	return s
+}
+
+// Validate reports the first selector that cannot be compiled.
+func (s Selectors) Validate() error {
+	_, err := s.compile()
+	return err
+}
+
+// compiledSelectors are Selectors with their defaults filled in and every
+// field compiled, so a page compiles each selector once instead of per row.
+type compiledSelectors struct {
+	Results    selector
+	Rows       selector
+	Info       selector
+	Date       selector
+	Title      selector
+	Price      selector
+	Hood       selector
+	Housing    selector
+	Images     selector
+	TotalCount selector
+	RangeFrom  selector
+	RangeTo    selector
+	NextPage   selector
+	NearbyArea selector
+}
+
+func (s Selectors) compile() (compiledSelectors, error) {
+	s = s.withDefaults()
+	compiled := compiledSelectors{}
+
+	var err error
+	compile := func(dst *selector, raw string) {
+		if err == nil {
+			*dst, err = compileSelector(raw)
+		}
+	}
+
+	compile(&compiled.Results, s.Results)
+	compile(&compiled.Rows, s.Rows)
+	compile(&compiled.Info, s.Info)
+	compile(&compiled.Date, s.Date)
+	compile(&compiled.Title, s.Title)
+	compile(&compiled.Price, s.Price)
+	compile(&compiled.Hood, s.Hood)
+	compile(&compiled.Housing, s.Housing)
+	compile(&compiled.Images, s.Images)
+	compile(&compiled.TotalCount, s.TotalCount)
+	compile(&compiled.RangeFrom, s.RangeFrom)
+	compile(&compiled.RangeTo, s.RangeTo)
+	compile(&compiled.NextPage, s.NextPage)
+	compile(&compiled.NearbyArea, s.NearbyArea)
+
+	return compiled, err
+}
+
+// selector is a compiled css selector, a list of alternatives.
+type selector []complexSelector
+
+// complexSelector is a chain of compounds, parts[i] is joined to parts[i+1]
+// by combinators[i] which is either ' ' (descendant) or '>' (child).
+type complexSelector struct {
+	parts       []compoundSelector
+	combinators []byte
+}
+
+type compoundSelector struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrSelector
+}
+
+type attrSelector struct {
+	key      string
+	val      string
+	hasValue bool
+}
+
+// compileSelector parses the supported subset of css described on Selectors.
+func compileSelector(raw string) (selector, error) {
+	outside, err := topLevel(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %v", raw, err)
+	}
+
+	alternatives := []string{}
+	start := 0
+	for i := range raw {
+		if outside[i] && raw[i] == ',' {
+			alternatives = append(alternatives, raw[start:i])
+			start = i + 1
+		}
+	}
+	alternatives = append(alternatives, raw[start:])
+
+	compiled := selector{}
+	for _, alternative := range alternatives {
+		complex, err := compileComplex(alternative)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %v", raw, err)
+		}
+		compiled = append(compiled, complex)
+	}
+
+	return compiled, nil
+}
+
+// topLevel marks the bytes of raw outside of attribute brackets, so that
+// separators within a quoted value like [title="a, b"] are left alone.
+func topLevel(raw string) ([]bool, error) {
+	outside := make([]bool, len(raw))
+	var quote byte
+	inside := false
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case inside:
+			if ch == '"' || ch == '\'' {
+				quote = ch
+			} else if ch == ']' {
+				inside = false
+			}
+		case ch == '[':
+			inside = true
+		default:
+			outside[i] = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed quote")
+	}
+	if inside {
+		return nil, fmt.Errorf("unclosed '['")
+	}
+
+	return outside, nil
+}
+
+func compileComplex(raw string) (complexSelector, error) {
+	complex := complexSelector{}
+
+	outside, err := topLevel(raw)
+	if err != nil {
+		return complex, err
+	}
+
+	// whitespace and child combinators split fields outside of brackets
+	fields := []string{}
+	field := strings.Builder{}
+	flush := func() {
+		if field.Len() > 0 {
+			fields = append(fields, field.String())
+			field.Reset()
+		}
+	}
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case outside[i] && raw[i] == '>':
+			flush()
+			fields = append(fields, ">")
+		case outside[i] && strings.IndexByte(" \t\n\r\f", raw[i]) >= 0:
+			flush()
+		default:
+			field.WriteByte(raw[i])
+		}
+	}
+	flush()
+
+	if len(fields) == 0 {
+		return complex, fmt.Errorf("empty selector")
+	}
+
+	combinator := byte(' ')
+	for i, field := range fields {
+		if field == ">" {
+			if i == 0 || i == len(fields)-1 || combinator == '>' {
+				return complex, fmt.Errorf("misplaced '>'")
+			}
+			combinator = '>'
+			continue
+		}
+
+		compound, err := compileCompound(field)
+		if err != nil {
+			return complex, err
+		}
+
+		if len(complex.parts) > 0 {
+			complex.combinators = append(complex.combinators, combinator)
+		}
+		complex.parts = append(complex.parts, compound)
+		combinator = ' '
+	}
+
+	return complex, nil
+}
+
+func compileCompound(raw string) (compoundSelector, error) {
+	compound := compoundSelector{}
+
+	name := func(s string) (string, string) {
+		end := strings.IndexAny(s, ".#[")
+		if end < 0 {
+			return s, ""
+		}
+		return s[:end], s[end:]
+	}
+
+	rest := raw
+	compound.tag, rest = name(rest)
+	if compound.tag == "*" {
+		compound.tag = ""
+	}
+
+	for rest != "" {
+		switch rest[0] {
+		case '.', '#':
+			prefix := rest[0]
+			var value string
+			value, rest = name(rest[1:])
+			if value == "" {
+				return compound, fmt.Errorf("empty name in %q", raw)
+			}
+			if prefix == '.' {
+				compound.classes = append(compound.classes, value)
+			} else {
+				compound.id = value
+			}
+		case '[':
+			end := attrEnd(rest)
+			if end < 0 {
+				return compound, fmt.Errorf("unclosed '[' in %q", raw)
+			}
+			attr := attrSelector{key: rest[1:end]}
+			if eq := strings.Index(attr.key, "="); eq >= 0 {
+				attr.val = attr.key[eq+1:]
+				attr.key = attr.key[:eq]
+				attr.hasValue = true
+				if len(attr.val) >= 2 && (attr.val[0] == '"' || attr.val[0] == '\'') && attr.val[len(attr.val)-1] == attr.val[0] {
+					attr.val = attr.val[1 : len(attr.val)-1]
+				}
+			}
+			if attr.key == "" {
+				return compound, fmt.Errorf("empty attribute in %q", raw)
+			}
+			compound.attrs = append(compound.attrs, attr)
+			rest = rest[end+1:]
+		default:
+			return compound, fmt.Errorf("unexpected %q in %q", rest[0], raw)
+		}
+	}
+
+	return compound, nil
+}
+
+// attrEnd returns the index of the bracket closing the attribute selector at
+// the start of s, skipping over quoted values, or -1 when it is not closed.
+func attrEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == ']':
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (c compoundSelector) matches(n *html.Node) bool {
+	if n == nil || n.Type != html.ElementNode {
+		return false
+	}
+
+	if c.tag != "" && !strings.EqualFold(c.tag, n.Data) {
+		return false
+	}
+
+	if c.id != "" {
+		if _, id := findAttr(n.Attr, "id"); id != c.id {
+			return false
+		}
+	}
+
+	if len(c.classes) > 0 {
+		_, class := findAttr(n.Attr, "class")
+		has := map[string]bool{}
+		for _, name := range strings.Fields(class) {
+			has[name] = true
+		}
+		for _, name := range c.classes {
+			if !has[name] {
+				return false
+			}
+		}
+	}
+
+	for _, attr := range c.attrs {
+		key, val := findAttr(n.Attr, attr.key)
+		if key == "" || (attr.hasValue && val != attr.val) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matches reports whether n matches the last compound and its ancestors
+// satisfy the compounds and combinators before it.
+func (c complexSelector) matches(n *html.Node) bool {
+	return c.matchesFrom(n, len(c.parts)-1)
+}
+
+func (c complexSelector) matchesFrom(n *html.Node, i int) bool {
+	if !c.parts[i].matches(n) {
+		return false
+	}
+
+	if i == 0 {
+		return true
+	}
+
+	if c.combinators[i-1] == '>' {
+		return c.matchesFrom(n.Parent, i-1)
+	}
+
+	for ancestor := n.Parent; ancestor != nil; ancestor = ancestor.Parent {
+		if c.matchesFrom(ancestor, i-1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s selector) matches(n *html.Node) bool {
+	for _, complex := range s {
+		if complex.matches(n) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// first returns the first descendant of n, in document order, that matches s.
+func (s selector) first(n *html.Node) (*html.Node, bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if s.matches(child) {
+			return child, true
+		}
+
+		if node, has := s.first(child); has {
+			return node, true
+		}
+	}
+
+	return nil, false
+}
+
+// querySelectorAll returns every descendant of n, in document order, that
+// matches raw. An invalid selector never matches.
+func querySelectorAll(n *html.Node, raw string) []*html.Node {
+	if n == nil {
+		return nil
+	}
+
+	compiled, err := compileSelector(raw)
+	if err != nil {
+		return nil
+	}
+
+	return compiled.all(n)
+}
+
+// all returns every descendant of n, in document order, that matches s.
+func (s selector) all(n *html.Node) []*html.Node {
+	if n == nil {
+		return nil
+	}
+
+	nodes := []*html.Node{}
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if s.matches(child) {
+				nodes = append(nodes, child)
+			}
+			walk(child)
+		}
+	}
+	walk(n)
+
+	return nodes
+}
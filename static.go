@@ -0,0 +1,66 @@
+package gocraigslist
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// StaticParser parses the static html search page craigslist serves to
+// clients without javascript, built from cl-static-search-result list items.
+// The layout carries neither posting dates nor a total count, so a cutoff
+// cannot be honored and the count of a Page is the number of listings on it.
+type StaticParser struct{}
+
+// selectors of the static layout, which cannot be overridden
+var (
+	staticRows, _     = compileSelector(".cl-static-search-results > .cl-static-search-result")
+	staticLink, _     = compileSelector("[href]")
+	staticTitle, _    = compileSelector(".title")
+	staticPrice, _    = compileSelector(".price")
+	staticLocation, _ = compileSelector(".location")
+)
+
+// Parse implements Parser. It returns an error when options has a Cutoff
+// rather than returning listings from before it.
+func (p *StaticParser) Parse(data io.Reader, options ParseOptions) (*Page, error) {
+	if options.Cutoff != nilTime {
+		return nil, fmt.Errorf("unable to apply cutoff: the static layout has no posting dates")
+	}
+
+	doc, err := html.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse data: %v", err)
+	}
+
+	listings := []Listing{}
+	for _, row := range staticRows.all(doc) {
+		linkNode, _ := staticLink.first(row)
+		var link string
+		if linkNode != nil {
+			_, link = findAttr(linkNode.Attr, "href")
+		}
+
+		_, title := findAttr(row.Attr, "title")
+		if titleNode, has := staticTitle.first(row); has {
+			title = findText(titleNode)
+		}
+
+		priceNode, _ := staticPrice.first(row)
+		locationNode, _ := staticLocation.first(row)
+
+		newListing := Listing{
+			Title: strings.TrimSpace(title),
+			Link:  link,
+			Price: findText(priceNode),
+			Hood:  findText(locationNode),
+		}
+		finishListing(&newListing)
+
+		listings = append(listings, newListing)
+	}
+
+	return &Page{Listings: listings, TotalCount: len(listings)}, nil
+}